@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"user_service/internal/bootstrap"
+	"user_service/internal/repository"
+	"user_service/internal/service"
+	"user_service/pkg/logging"
+)
+
+// reconcile recomputes follower counters once and exits.
+func main() {
+	logging.InitLogger()
+
+	batchSize := flag.Int("batch-size", 0, "users per batch, defaults to RECONCILE_BATCH_SIZE")
+	flag.Parse()
+
+	bs, err := bootstrap.Init()
+	if err != nil {
+		logging.Instance.Fatal(err)
+	}
+
+	ci, err := bs.GetRepository("counter")
+	if err != nil {
+		logging.Instance.Fatal(err)
+	}
+
+	counters, ok := ci.(repository.FollowCounterRepository)
+	if !ok {
+		logging.Instance.Fatal("counter repository has unexpected type")
+	}
+
+	size := bs.Config.Reconcile.BatchSize
+	if *batchSize > 0 {
+		size = *batchSize
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fixed, err := service.NewCounterReconciler(counters, size).Reconcile(ctx)
+	if err != nil {
+		logging.Instance.Fatalf("Reconciliation stopped after correcting %d users: %v", fixed, err)
+	}
+
+	logging.Instance.Infof("✅ Reconciliation finished, corrected %d users", fixed)
+}
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"context"
 	"github.com/gin-gonic/gin"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	"user_service/internal/bootstrap"
+	"user_service/internal/middleware"
+	"user_service/internal/repository"
 	"user_service/internal/routes"
+	"user_service/internal/service"
 	"user_service/pkg/logging"
 )
 
@@ -16,9 +20,12 @@ func main() {
 		logging.Instance.Error(err)
 	}
 
+	startBackgroundJobs(context.Background(), bs)
+
 	gin.SetMode(gin.ReleaseMode)
 	r := gin.New()
 	r.Use(logging.Middleware)
+	r.Use(middleware.ErrorMiddleware())
 
 	routes.SetupRoutes(r, bs)
 	logging.Instance.Info("Starting application or port :" + bs.Config.Port)
@@ -28,3 +35,51 @@ func main() {
 		return
 	}
 }
+
+func startBackgroundJobs(ctx context.Context, bs *bootstrap.Container) {
+	if bs.Config.Auth.JWKSURL != "" && bs.Config.Auth.JWKSRefreshInterval > 0 {
+		go bs.Verifier.RefreshKeys(ctx, bs.Config.Auth.JWKSRefreshInterval)
+		logging.Instance.Info("JWKS refresh scheduled every ", bs.Config.Auth.JWKSRefreshInterval)
+	}
+
+	if bs.Config.Auth.RevocationPurgeInterval > 0 {
+		go bs.Revocations.Run(ctx, bs.Config.Auth.RevocationPurgeInterval)
+		logging.Instance.Info("Revoked token purge scheduled every ", bs.Config.Auth.RevocationPurgeInterval)
+	}
+
+	if bs.Config.Reconcile.Interval > 0 {
+		ci, err := bs.GetRepository("counter")
+		if err != nil {
+			logging.Instance.Error(err)
+			return
+		}
+
+		counters, ok := ci.(repository.FollowCounterRepository)
+		if !ok {
+			logging.Instance.Error("counter repository has unexpected type")
+			return
+		}
+
+		reconciler := service.NewCounterReconciler(counters, bs.Config.Reconcile.BatchSize)
+		go reconciler.Run(ctx, bs.Config.Reconcile.Interval)
+		logging.Instance.Info("Follower counter reconciliation scheduled every ", bs.Config.Reconcile.Interval)
+	}
+
+	if bs.Config.Storage.GCInterval > 0 {
+		ui, err := bs.GetRepository("user")
+		if err != nil {
+			logging.Instance.Error(err)
+			return
+		}
+
+		users, ok := ui.(*repository.UserRepositoryImpl)
+		if !ok {
+			logging.Instance.Error("user repository has unexpected type")
+			return
+		}
+
+		collector := service.NewBlobCollector(users, bs.Storage)
+		go collector.Run(ctx, bs.Config.Storage.GCInterval)
+		logging.Instance.Info("Orphaned media collection scheduled every ", bs.Config.Storage.GCInterval)
+	}
+}
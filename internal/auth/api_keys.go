@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+	"user_service/internal/model"
+	"user_service/pkg/logging"
+)
+
+var (
+	ErrInvalidAPIKey = &Error{Code: "invalid_api_key", Description: "API key is invalid"}
+	ErrAPIKeyExpired = &Error{Code: "api_key_expired", Description: "API key has expired"}
+	ErrAPIKeyRevoked = &Error{Code: "api_key_revoked", Description: "API key has been revoked"}
+)
+
+// apiKeyPrefix marks API keys so they are recognisable in configs and leaks.
+const apiKeyPrefix = "usk_"
+
+// displayPrefixLength is how much of a key is kept in clear to identify it.
+const displayPrefixLength = len(apiKeyPrefix) + 8
+
+// touchInterval throttles last-used updates of a busy key.
+const touchInterval = time.Minute
+
+// APIKeyStore persists API keys.
+type APIKeyStore interface {
+	// FindAPIKey returns the key with the given hash, or nil when there is none.
+	FindAPIKey(hash string) (*model.APIKey, error)
+	TouchAPIKey(id uint, at time.Time) error
+}
+
+type cachedAPIKey struct {
+	key     *model.APIKey
+	fetched time.Time
+}
+
+// APIKeys authenticates service callers by API key. Known keys are cached for
+// cacheTTL, so keys revoked by another instance stop working within that time;
+// those revoked through this one do at once.
+type APIKeys struct {
+	store    APIKeyStore
+	cacheTTL time.Duration
+
+	mu       sync.Mutex
+	keys     map[string]cachedAPIKey
+	lastUsed map[uint]time.Time
+}
+
+func NewAPIKeys(store APIKeyStore, cacheTTL time.Duration) *APIKeys {
+	return &APIKeys{
+		store:    store,
+		cacheTTL: cacheTTL,
+		keys:     make(map[string]cachedAPIKey),
+		lastUsed: make(map[uint]time.Time),
+	}
+}
+
+// GenerateAPIKey returns a new random key together with its display prefix and
+// the hash to store.
+func GenerateAPIKey() (key, prefix, hash string, err error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", "", err
+	}
+
+	key = apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	return key, key[:displayPrefixLength], HashAPIKey(key), nil
+}
+
+// HashAPIKey hashes a key for storage. Keys carry 256 random bits, so a fast
+// unsalted hash is enough to keep a database leak from revealing them.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Authenticate returns the principal of a valid key. Rejections are reported
+// as *Error, any other error means the key could not be checked.
+func (k *APIKeys) Authenticate(ctx context.Context, rawKey string) (*Principal, error) {
+	if !strings.HasPrefix(rawKey, apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := k.lookup(HashAPIKey(rawKey))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	switch {
+	case key == nil:
+		return nil, ErrInvalidAPIKey
+	case key.RevokedAt != nil:
+		return nil, ErrAPIKeyRevoked
+	case key.ExpiresAt != nil && !now.Before(*key.ExpiresAt):
+		return nil, ErrAPIKeyExpired
+	}
+
+	k.touch(key.ID, now)
+	return &Principal{APIKeyID: key.ID, Scopes: key.ScopeList()}, nil
+}
+
+// Forget drops key id from the cache after it was revoked.
+func (k *APIKeys) Forget(id uint) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	for hash, entry := range k.keys {
+		if entry.key.ID == id {
+			delete(k.keys, hash)
+		}
+	}
+	delete(k.lastUsed, id)
+}
+
+// lookup finds a key by hash. Unknown hashes are not cached, so guessing keys
+// cannot fill up memory.
+func (k *APIKeys) lookup(hash string) (*model.APIKey, error) {
+	k.mu.Lock()
+	entry, ok := k.keys[hash]
+	k.mu.Unlock()
+	if ok && time.Since(entry.fetched) < k.cacheTTL {
+		return entry.key, nil
+	}
+
+	key, err := k.store.FindAPIKey(hash)
+	if err != nil || key == nil {
+		return nil, err
+	}
+
+	k.mu.Lock()
+	k.keys[hash] = cachedAPIKey{key: key, fetched: time.Now()}
+	k.mu.Unlock()
+	return key, nil
+}
+
+// touch records the use of key id at most once per touchInterval. Failures are
+// only logged: the timestamp is informational.
+func (k *APIKeys) touch(id uint, now time.Time) {
+	k.mu.Lock()
+	last, ok := k.lastUsed[id]
+	if ok && now.Sub(last) < touchInterval {
+		k.mu.Unlock()
+		return
+	}
+	k.lastUsed[id] = now
+	k.mu.Unlock()
+
+	if err := k.store.TouchAPIKey(id, now); err != nil {
+		logging.Instance.WithField("api_key_id", id).Error("Recording API key use failed: ", err)
+	}
+}
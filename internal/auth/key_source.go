@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"user_service/pkg/logging"
+)
+
+const (
+	// minRefreshInterval rate limits refreshes triggered by tokens with an unknown kid.
+	minRefreshInterval = time.Minute
+	maxJWKSSize        = 1 << 20
+)
+
+// KeySource loads the verification keys of a JWKS document from a file or an
+// http(s) URL and keeps them cached. Keys are refreshed periodically by Run and
+// on demand when a token names a kid the cache does not know, which picks up
+// rotated keys before the next periodic refresh.
+type KeySource struct {
+	location string
+	client   *http.Client
+
+	// refreshMu serializes refreshes, mu guards the cached keys.
+	refreshMu sync.Mutex
+	mu        sync.RWMutex
+	keys      map[string]verificationKey
+	fetchedAt time.Time
+}
+
+// NewKeySource creates a source for location, which is an http(s) URL, a
+// file:// URL or a plain file path. Keys are loaded by the first Refresh.
+func NewKeySource(location string) *KeySource {
+	return &KeySource{location: location, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Refresh reloads the keys. On failure the previously loaded keys stay in use.
+func (s *KeySource) Refresh(ctx context.Context) error {
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+	return s.refresh(ctx)
+}
+
+func (s *KeySource) refresh(ctx context.Context) error {
+	data, err := s.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.fetchedAt = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+// Run refreshes the keys every interval until ctx is cancelled.
+func (s *KeySource) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Refresh(ctx); err != nil {
+				logging.Instance.Error("JWKS refresh failed: ", err)
+			}
+		}
+	}
+}
+
+// key returns the key with kid, refreshing once if it is unknown and the last
+// refresh is older than minRefreshInterval.
+func (s *KeySource) key(ctx context.Context, kid string) (verificationKey, bool) {
+	if key, ok := s.cached(kid); ok {
+		return key, true
+	}
+
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+
+	// Another request may have refreshed while this one waited for the lock.
+	if key, ok := s.cached(kid); ok {
+		return key, true
+	}
+
+	s.mu.RLock()
+	recent := time.Since(s.fetchedAt) < minRefreshInterval
+	s.mu.RUnlock()
+	if recent {
+		return verificationKey{}, false
+	}
+
+	if err := s.refresh(ctx); err != nil {
+		logging.Instance.Error("JWKS refresh for unknown kid failed: ", err)
+		return verificationKey{}, false
+	}
+	return s.cached(kid)
+}
+
+func (s *KeySource) cached(kid string) (verificationKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+func (s *KeySource) load(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(s.location, "http://") && !strings.HasPrefix(s.location, "https://") {
+		return os.ReadFile(strings.TrimPrefix(s.location, "file://"))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.location, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch JWKS from %s: %s", s.location, res.Status)
+	}
+	return io.ReadAll(io.LimitReader(res.Body, maxJWKSSize))
+}
@@ -0,0 +1,187 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+	"user_service/pkg/logging"
+)
+
+// RevocationStore persists revoked tokens and per-user cutoffs.
+type RevocationStore interface {
+	RevokeToken(jti string, userID uint, expiresAt time.Time) error
+	IsTokenRevoked(jti string) (bool, error)
+	RevokeUserTokens(userID uint, before time.Time) error
+	UserTokensRevokedBefore(userID uint) (time.Time, error)
+	DeleteExpiredTokens(before time.Time) (int64, error)
+}
+
+// expiredTokenRetention keeps revoked tokens around after their exp, well beyond
+// any sensible clock skew leeway.
+const expiredTokenRetention = time.Hour
+
+type cachedCutoff struct {
+	before  time.Time
+	fetched time.Time
+}
+
+type cachedToken struct {
+	revoked bool
+	fetched time.Time
+}
+
+// RevocationList decides whether a verified token was revoked before its expiry,
+// either by its jti or by a cutoff covering every token of its user issued
+// before it. Lookups are cached for cacheTTL, so revocations made by another
+// instance take effect within that time; those made through this one do at once.
+type RevocationList struct {
+	store    RevocationStore
+	cacheTTL time.Duration
+
+	mu      sync.Mutex
+	cutoffs map[uint]cachedCutoff
+	tokens  map[string]cachedToken
+	// evicted is when stale entries were last dropped.
+	evicted time.Time
+}
+
+func NewRevocationList(store RevocationStore, cacheTTL time.Duration) *RevocationList {
+	return &RevocationList{
+		store:    store,
+		cacheTTL: cacheTTL,
+		cutoffs:  make(map[uint]cachedCutoff),
+		tokens:   make(map[string]cachedToken),
+	}
+}
+
+// Revoked reports whether the token of p has been revoked.
+func (l *RevocationList) Revoked(ctx context.Context, p *Principal) (bool, error) {
+	cutoff, err := l.cutoff(p.UserID)
+	if err != nil {
+		return false, err
+	}
+	// iat has second precision, so a token issued in the second of the cutoff
+	// counts as revoked. Without iat it cannot be told apart from older tokens.
+	if !cutoff.IsZero() && (p.IssuedAt.IsZero() || p.IssuedAt.Unix() <= cutoff.Unix()) {
+		return true, nil
+	}
+
+	if p.TokenID == "" {
+		return false, nil
+	}
+	return l.tokenRevoked(p.TokenID)
+}
+
+// RevokeToken revokes the single token of p, which must carry a jti.
+func (l *RevocationList) RevokeToken(ctx context.Context, p *Principal) error {
+	if err := l.store.RevokeToken(p.TokenID, p.UserID, p.ExpiresAt); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	l.mu.Lock()
+	l.evictStale(now)
+	l.tokens[p.TokenID] = cachedToken{revoked: true, fetched: now}
+	l.mu.Unlock()
+	return nil
+}
+
+// RevokeUser revokes every token of userID issued up to now.
+func (l *RevocationList) RevokeUser(ctx context.Context, userID uint) error {
+	now := time.Now()
+	if err := l.store.RevokeUserTokens(userID, now); err != nil {
+		return err
+	}
+
+	// Drop the entry instead of caching now: the stored cutoff may be later.
+	l.mu.Lock()
+	delete(l.cutoffs, userID)
+	l.mu.Unlock()
+	return nil
+}
+
+func (l *RevocationList) cutoff(userID uint) (time.Time, error) {
+	l.mu.Lock()
+	entry, ok := l.cutoffs[userID]
+	l.mu.Unlock()
+	if ok && time.Since(entry.fetched) < l.cacheTTL {
+		return entry.before, nil
+	}
+
+	before, err := l.store.UserTokensRevokedBefore(userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	now := time.Now()
+	l.mu.Lock()
+	l.evictStale(now)
+	l.cutoffs[userID] = cachedCutoff{before: before, fetched: now}
+	l.mu.Unlock()
+	return before, nil
+}
+
+func (l *RevocationList) tokenRevoked(jti string) (bool, error) {
+	l.mu.Lock()
+	entry, ok := l.tokens[jti]
+	l.mu.Unlock()
+	// Revocation is permanent, only negative answers need refreshing.
+	if ok && (entry.revoked || time.Since(entry.fetched) < l.cacheTTL) {
+		return entry.revoked, nil
+	}
+
+	revoked, err := l.store.IsTokenRevoked(jti)
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	l.mu.Lock()
+	l.evictStale(now)
+	l.tokens[jti] = cachedToken{revoked: revoked, fetched: now}
+	l.mu.Unlock()
+	return revoked, nil
+}
+
+// Run periodically deletes revoked tokens that have expired until ctx is cancelled.
+func (l *RevocationList) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := l.store.DeleteExpiredTokens(time.Now().Add(-expiredTokenRetention))
+			if err != nil {
+				logging.Instance.Error("Purging expired revoked tokens failed: ", err)
+				continue
+			}
+			if deleted > 0 {
+				logging.Instance.Info("Purged ", deleted, " expired revoked tokens")
+			}
+		}
+	}
+}
+
+// evictStale drops cache entries older than the cache TTL to bound memory,
+// sweeping at most once per TTL as entries are added. Evicted revoked tokens
+// are simply looked up again. l.mu must be held.
+func (l *RevocationList) evictStale(now time.Time) {
+	if now.Sub(l.evicted) < l.cacheTTL {
+		return
+	}
+	l.evicted = now
+
+	for userID, entry := range l.cutoffs {
+		if now.Sub(entry.fetched) >= l.cacheTTL {
+			delete(l.cutoffs, userID)
+		}
+	}
+	for jti, entry := range l.tokens {
+		if now.Sub(entry.fetched) >= l.cacheTTL {
+			delete(l.tokens, jti)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// memRevocations is a RevocationStore in memory counting its lookups.
+type memRevocations struct {
+	tokens  map[string]bool
+	cutoffs map[uint]time.Time
+	lookups int
+}
+
+func newMemRevocations() *memRevocations {
+	return &memRevocations{tokens: make(map[string]bool), cutoffs: make(map[uint]time.Time)}
+}
+
+func (m *memRevocations) RevokeToken(jti string, _ uint, _ time.Time) error {
+	m.tokens[jti] = true
+	return nil
+}
+
+func (m *memRevocations) IsTokenRevoked(jti string) (bool, error) {
+	m.lookups++
+	return m.tokens[jti], nil
+}
+
+func (m *memRevocations) RevokeUserTokens(userID uint, before time.Time) error {
+	m.cutoffs[userID] = before
+	return nil
+}
+
+func (m *memRevocations) UserTokensRevokedBefore(userID uint) (time.Time, error) {
+	m.lookups++
+	return m.cutoffs[userID], nil
+}
+
+func (m *memRevocations) DeleteExpiredTokens(time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestRevocationListRevoked(t *testing.T) {
+	cutoff := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := newMemRevocations()
+	store.cutoffs[1] = cutoff
+	store.tokens["revoked"] = true
+	list := NewRevocationList(store, time.Minute)
+
+	tests := []struct {
+		name      string
+		principal Principal
+		want      bool
+	}{
+		{"no cutoff", Principal{UserID: 2, TokenID: "a", IssuedAt: cutoff}, false},
+		{"issued before cutoff", Principal{UserID: 1, TokenID: "a", IssuedAt: cutoff.Add(-time.Hour)}, true},
+		{"issued in the second of the cutoff", Principal{UserID: 1, TokenID: "a", IssuedAt: cutoff.Add(500 * time.Millisecond)}, true},
+		{"issued after cutoff", Principal{UserID: 1, TokenID: "a", IssuedAt: cutoff.Add(time.Second)}, false},
+		{"no iat under a cutoff", Principal{UserID: 1, TokenID: "a"}, true},
+		{"no iat without cutoff", Principal{UserID: 2, TokenID: "a"}, false},
+		{"revoked jti", Principal{UserID: 2, TokenID: "revoked", IssuedAt: cutoff}, true},
+		{"no jti", Principal{UserID: 2, IssuedAt: cutoff}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := list.Revoked(context.Background(), &tt.principal)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Revoked = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRevocationListCaches(t *testing.T) {
+	store := newMemRevocations()
+	list := NewRevocationList(store, time.Minute)
+	ctx := context.Background()
+	p := &Principal{UserID: 1, TokenID: "jti", IssuedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}
+
+	for range 3 {
+		if revoked, _ := list.Revoked(ctx, p); revoked {
+			t.Fatal("token revoked before RevokeToken")
+		}
+	}
+	if store.lookups != 2 {
+		t.Errorf("%d store lookups for three checks, want 2", store.lookups)
+	}
+
+	// Revocations through the same list apply at once despite the cache.
+	if err := list.RevokeToken(ctx, p); err != nil {
+		t.Fatal(err)
+	}
+	if revoked, _ := list.Revoked(ctx, p); !revoked {
+		t.Error("token not revoked after RevokeToken")
+	}
+
+	other := &Principal{UserID: 2, TokenID: "other", IssuedAt: time.Now().Add(-time.Second)}
+	if revoked, _ := list.Revoked(ctx, other); revoked {
+		t.Fatal("token revoked before RevokeUser")
+	}
+	if err := list.RevokeUser(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if revoked, _ := list.Revoked(ctx, other); !revoked {
+		t.Error("token not revoked after RevokeUser")
+	}
+}
+
+func TestRevocationListEvictsStaleEntries(t *testing.T) {
+	list := NewRevocationList(newMemRevocations(), time.Minute)
+	stale := time.Now().Add(-time.Hour)
+	for i := range 100 {
+		list.cutoffs[uint(i+10)] = cachedCutoff{fetched: stale}
+		list.tokens[string(rune('a'+i))] = cachedToken{fetched: stale}
+	}
+	list.evicted = stale
+
+	p := &Principal{UserID: 1, TokenID: "jti", IssuedAt: time.Now()}
+	if _, err := list.Revoked(context.Background(), p); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.cutoffs) != 1 || len(list.tokens) != 1 {
+		t.Errorf("cache holds %d cutoffs and %d tokens, want only the fresh ones", len(list.cutoffs), len(list.tokens))
+	}
+
+	// The next sweep is a TTL away.
+	list.cutoffs[99] = cachedCutoff{fetched: stale}
+	list.Revoked(context.Background(), &Principal{UserID: 3, TokenID: "next", IssuedAt: time.Now()})
+	if _, ok := list.cutoffs[99]; !ok {
+		t.Error("swept again within the TTL")
+	}
+}
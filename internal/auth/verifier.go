@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Error is a token rejection with a machine-readable code for API clients.
+type Error struct {
+	Code        string
+	Description string
+}
+
+func (e *Error) Error() string {
+	return e.Description
+}
+
+var (
+	ErrMalformedToken   = &Error{Code: "malformed_token", Description: "token is malformed"}
+	ErrInvalidSignature = &Error{Code: "invalid_signature", Description: "token signature is invalid"}
+	ErrUnknownKey       = &Error{Code: "unknown_key", Description: "token is signed with an unknown key"}
+	ErrTokenExpired     = &Error{Code: "token_expired", Description: "token has expired"}
+	ErrTokenNotYetValid = &Error{Code: "token_not_yet_valid", Description: "token is not valid yet"}
+	ErrMissingClaim     = &Error{Code: "missing_claim", Description: "token lacks a required claim"}
+	ErrInvalidIssuer    = &Error{Code: "invalid_issuer", Description: "token issuer is not accepted"}
+	ErrInvalidAudience  = &Error{Code: "invalid_audience", Description: "token is not meant for this service"}
+	ErrInvalidSubject   = &Error{Code: "invalid_subject", Description: "token subject is not a valid user id"}
+	ErrInvalidClaim     = &Error{Code: "invalid_claim", Description: "token has a malformed scope or roles claim"}
+	ErrTokenRevoked     = &Error{Code: "token_revoked", Description: "token has been revoked"}
+	ErrInvalidToken     = &Error{Code: "invalid_token", Description: "token is invalid"}
+)
+
+// asymmetricAlgorithms are verified with keys from the JWKS, the HS* family with
+// the shared secret.
+var asymmetricAlgorithms = []string{
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+}
+
+var hmacAlgorithms = []string{"HS256", "HS384", "HS512"}
+
+// Config configures a Verifier.
+type Config struct {
+	// Algorithms lists the accepted signing algorithms, tokens using any other are rejected.
+	Algorithms []string
+	// HMACSecret verifies HS256/384/512 tokens. Anyone holding it can also mint
+	// tokens, so it is meant for local development.
+	HMACSecret []byte
+	// Keys provides the public keys for the asymmetric algorithms.
+	Keys *KeySource
+	// Issuers lists the accepted iss values, empty accepts any issuer.
+	Issuers []string
+	// Audiences lists the audiences of this service. When set, a token's aud
+	// must name at least one of them.
+	Audiences []string
+	// Leeway tolerates clock skew between the issuer and this service when
+	// checking exp, nbf and iat.
+	Leeway time.Duration
+	// DefaultScopes are granted to tokens without a scope claim, nil grants
+	// the package DefaultScopes.
+	DefaultScopes []string
+	// Revocations rejects tokens revoked before their expiry, nil disables the check.
+	Revocations *RevocationList
+	// APIKeys authenticates service callers, nil rejects every API key.
+	APIKeys *APIKeys
+}
+
+// Verifier checks bearer tokens and API keys and extracts the authenticated caller.
+type Verifier struct {
+	cfg    Config
+	parser *jwt.Parser
+}
+
+func NewVerifier(cfg Config) (*Verifier, error) {
+	if len(cfg.Algorithms) == 0 {
+		return nil, errors.New("no token signing algorithms allowed")
+	}
+
+	for _, alg := range cfg.Algorithms {
+		switch {
+		case slices.Contains(hmacAlgorithms, alg):
+			if len(cfg.HMACSecret) == 0 {
+				return nil, fmt.Errorf("algorithm %s needs an HMAC secret", alg)
+			}
+		case slices.Contains(asymmetricAlgorithms, alg):
+			if cfg.Keys == nil {
+				return nil, fmt.Errorf("algorithm %s needs a JWKS", alg)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported token signing algorithm %q", alg)
+		}
+	}
+
+	if cfg.DefaultScopes == nil {
+		cfg.DefaultScopes = DefaultScopes
+	}
+
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(cfg.Algorithms),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(cfg.Leeway),
+	)
+	return &Verifier{cfg: cfg, parser: parser}, nil
+}
+
+// Verify checks the signature and claims of tokenString and returns the caller
+// it authenticates. Rejections are reported as *Error, any other error means
+// the token could not be checked.
+func (v *Verifier) Verify(ctx context.Context, tokenString string) (*Principal, error) {
+	claims := jwt.MapClaims{}
+
+	_, err := v.parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return v.key(ctx, token)
+	})
+	if err != nil {
+		return nil, parseError(err)
+	}
+
+	if len(v.cfg.Issuers) > 0 {
+		iss, err := claims.GetIssuer()
+		if err != nil || !slices.Contains(v.cfg.Issuers, iss) {
+			return nil, ErrInvalidIssuer
+		}
+	}
+
+	if len(v.cfg.Audiences) > 0 {
+		aud, err := claims.GetAudience()
+		if err != nil || !slices.ContainsFunc(aud, func(a string) bool { return slices.Contains(v.cfg.Audiences, a) }) {
+			return nil, ErrInvalidAudience
+		}
+	}
+
+	sub, err := claims.GetSubject()
+	if err != nil || sub == "" {
+		return nil, ErrInvalidSubject
+	}
+
+	userID, err := strconv.ParseUint(sub, 10, 32)
+	if err != nil || userID == 0 {
+		return nil, ErrInvalidSubject
+	}
+	principal, err := newPrincipal(uint(userID), claims, v.cfg.DefaultScopes)
+	if err != nil {
+		return nil, err
+	}
+
+	if v.cfg.Revocations != nil {
+		revoked, err := v.cfg.Revocations.Revoked(ctx, principal)
+		if err != nil {
+			return nil, fmt.Errorf("check token revocation: %w", err)
+		}
+		if revoked {
+			return nil, ErrTokenRevoked
+		}
+	}
+	return principal, nil
+}
+
+// VerifyAPIKey authenticates a service caller by its API key. Rejections are
+// reported as *Error.
+func (v *Verifier) VerifyAPIKey(ctx context.Context, key string) (*Principal, error) {
+	if v.cfg.APIKeys == nil {
+		return nil, ErrInvalidAPIKey
+	}
+	return v.cfg.APIKeys.Authenticate(ctx, key)
+}
+
+// parseError maps a jwt parsing failure to the matching *Error.
+func parseError(err error) error {
+	switch {
+	case errors.Is(err, ErrUnknownKey):
+		return ErrUnknownKey
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return ErrMalformedToken
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid), errors.Is(err, jwt.ErrTokenUnverifiable):
+		return ErrInvalidSignature
+	case errors.Is(err, jwt.ErrTokenRequiredClaimMissing):
+		return ErrMissingClaim
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return ErrTokenExpired
+	case errors.Is(err, jwt.ErrTokenNotValidYet), errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+		return ErrTokenNotYetValid
+	}
+	return ErrInvalidToken
+}
+
+// key selects the verification key for token. The parser has already checked
+// its algorithm against the allow-list, and each algorithm family only ever
+// gets keys of its own kind, so a public key can never be used as HMAC secret.
+func (v *Verifier) key(ctx context.Context, token *jwt.Token) (interface{}, error) {
+	alg := token.Method.Alg()
+	if strings.HasPrefix(alg, "HS") {
+		return v.cfg.HMACSecret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := v.cfg.Keys.key(ctx, kid)
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	if key.alg != "" && key.alg != alg {
+		return nil, fmt.Errorf("key %q is not meant for %s", kid, alg)
+	}
+	return key.key, nil
+}
+
+// RefreshKeys keeps the JWKS keys up to date until ctx is cancelled. It returns
+// immediately when the verifier has no JWKS.
+func (v *Verifier) RefreshKeys(ctx context.Context, interval time.Duration) {
+	if v.cfg.Keys == nil || interval <= 0 {
+		return
+	}
+	v.cfg.Keys.Run(ctx, interval)
+}
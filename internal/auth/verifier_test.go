@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+var hmacSecret = []byte("test secret")
+
+// testKeys writes a JWKS holding the public half of key under kid "rs",
+// and under kid "rs512" pinned to RS512, and returns a source loaded from it.
+func testKeys(t *testing.T, key *rsa.PrivateKey) *KeySource {
+	t.Helper()
+
+	jwk := func(kid, alg string) jsonWebKey {
+		return jsonWebKey{
+			Kty: "RSA",
+			Kid: kid,
+			Use: "sig",
+			Alg: alg,
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}
+	}
+	data, err := json.Marshal(map[string][]jsonWebKey{"keys": {jwk("rs", ""), jwk("rs512", "RS512")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := NewKeySource(path)
+	if err := keys.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+// sign returns a token over claims signed with method and key, naming kid
+// in its header when set.
+func sign(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// validClaims returns claims every test verifier accepts, with overrides
+// applied and nil overrides removing the claim.
+func validClaims(overrides jwt.MapClaims) jwt.MapClaims {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"sub": "42",
+		"iss": "https://issuer.test",
+		"aud": "user_service",
+		"iat": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+	}
+	for name, value := range overrides {
+		if value == nil {
+			delete(claims, name)
+			continue
+		}
+		claims[name] = value
+	}
+	return claims
+}
+
+func TestVerifierVerify(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// publicPEM is what an attacker would try as HMAC secret.
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	keys := testKeys(t, key)
+
+	newVerifier := func(algorithms ...string) *Verifier {
+		v, err := NewVerifier(Config{
+			Algorithms: algorithms,
+			HMACSecret: hmacSecret,
+			Keys:       keys,
+			Issuers:    []string{"https://issuer.test"},
+			Audiences:  []string{"user_service"},
+			Leeway:     30 * time.Second,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	rsOnly := newVerifier("RS256", "RS512")
+	both := newVerifier("RS256", "HS256")
+	hsOnly := newVerifier("HS256")
+
+	now := time.Now()
+	tests := []struct {
+		name     string
+		verifier *Verifier
+		token    string
+		want     error
+	}{
+		{"RS256", rsOnly, sign(t, jwt.SigningMethodRS256, key, "rs", validClaims(nil)), nil},
+		{"HS256", hsOnly, sign(t, jwt.SigningMethodHS256, hmacSecret, "", validClaims(nil)), nil},
+		{"audience among several", rsOnly, sign(t, jwt.SigningMethodRS256, key, "rs", validClaims(jwt.MapClaims{"aud": []string{"other", "user_service"}})), nil},
+		{"expired within leeway", rsOnly, sign(t, jwt.SigningMethodRS256, key, "rs", validClaims(jwt.MapClaims{"exp": now.Add(-10 * time.Second).Unix()})), nil},
+
+		{"algorithm not allowed", hsOnly, sign(t, jwt.SigningMethodRS256, key, "rs", validClaims(nil)), ErrInvalidSignature},
+		{"RS384 not allowed", rsOnly, sign(t, jwt.SigningMethodRS384, key, "rs", validClaims(nil)), ErrInvalidSignature},
+		{"alg none", both, sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "", validClaims(nil)), ErrInvalidSignature},
+		{"HS256 with public key when only RS allowed", rsOnly, sign(t, jwt.SigningMethodHS256, publicPEM, "rs", validClaims(nil)), ErrInvalidSignature},
+		{"HS256 with public key when both allowed", both, sign(t, jwt.SigningMethodHS256, publicPEM, "rs", validClaims(nil)), ErrInvalidSignature},
+		{"key pinned to another algorithm", rsOnly, sign(t, jwt.SigningMethodRS256, key, "rs512", validClaims(nil)), ErrInvalidSignature},
+		{"signed by another key", rsOnly, sign(t, jwt.SigningMethodRS256, otherKey, "rs", validClaims(nil)), ErrInvalidSignature},
+		{"unknown kid", rsOnly, sign(t, jwt.SigningMethodRS256, key, "gone", validClaims(nil)), ErrUnknownKey},
+		{"wrong HMAC secret", hsOnly, sign(t, jwt.SigningMethodHS256, []byte("guess"), "", validClaims(nil)), ErrInvalidSignature},
+		{"malformed", rsOnly, "not.a.token", ErrMalformedToken},
+
+		{"missing exp", rsOnly, sign(t, jwt.SigningMethodRS256, key, "rs", validClaims(jwt.MapClaims{"exp": nil})), ErrMissingClaim},
+		{"expired", rsOnly, sign(t, jwt.SigningMethodRS256, key, "rs", validClaims(jwt.MapClaims{"exp": now.Add(-time.Minute).Unix()})), ErrTokenExpired},
+		{"not yet valid", rsOnly, sign(t, jwt.SigningMethodRS256, key, "rs", validClaims(jwt.MapClaims{"nbf": now.Add(time.Minute).Unix()})), ErrTokenNotYetValid},
+		{"issued in the future", rsOnly, sign(t, jwt.SigningMethodRS256, key, "rs", validClaims(jwt.MapClaims{"iat": now.Add(time.Minute).Unix()})), ErrTokenNotYetValid},
+		{"wrong issuer", rsOnly, sign(t, jwt.SigningMethodRS256, key, "rs", validClaims(jwt.MapClaims{"iss": "https://evil.test"})), ErrInvalidIssuer},
+		{"missing issuer", rsOnly, sign(t, jwt.SigningMethodRS256, key, "rs", validClaims(jwt.MapClaims{"iss": nil})), ErrInvalidIssuer},
+		{"wrong audience", rsOnly, sign(t, jwt.SigningMethodRS256, key, "rs", validClaims(jwt.MapClaims{"aud": "other_service"})), ErrInvalidAudience},
+		{"missing audience", rsOnly, sign(t, jwt.SigningMethodRS256, key, "rs", validClaims(jwt.MapClaims{"aud": nil})), ErrInvalidAudience},
+		{"non numeric subject", rsOnly, sign(t, jwt.SigningMethodRS256, key, "rs", validClaims(jwt.MapClaims{"sub": "alice"})), ErrInvalidSubject},
+		{"zero subject", rsOnly, sign(t, jwt.SigningMethodRS256, key, "rs", validClaims(jwt.MapClaims{"sub": "0"})), ErrInvalidSubject},
+		{"malformed scope", rsOnly, sign(t, jwt.SigningMethodRS256, key, "rs", validClaims(jwt.MapClaims{"scope": 7})), ErrInvalidClaim},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := tt.verifier.Verify(context.Background(), tt.token)
+			if tt.want != nil {
+				if !errors.Is(err, tt.want) {
+					t.Fatalf("Verify error = %v, want %v", err, tt.want)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if principal.UserID != 42 || !slices.Equal(principal.Scopes, DefaultScopes) {
+				t.Errorf("principal = %+v, want user 42 with the default scopes", principal)
+			}
+		})
+	}
+}
+
+func TestNewVerifier(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"no algorithms", Config{HMACSecret: hmacSecret}},
+		{"HMAC without secret", Config{Algorithms: []string{"HS256"}}},
+		{"RSA without keys", Config{Algorithms: []string{"RS256"}, HMACSecret: hmacSecret}},
+		{"none", Config{Algorithms: []string{"none"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewVerifier(tt.cfg); err == nil {
+				t.Error("NewVerifier accepted the config")
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package bootstrap
 
 import (
+	"context"
+	"crypto/rand"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -12,8 +14,10 @@ import (
 	"gorm.io/gorm"
 
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"user_service/internal/auth"
 	"user_service/internal/config"
 	"user_service/internal/repository"
+	"user_service/internal/storage"
 	"user_service/pkg/logging"
 )
 
@@ -21,6 +25,11 @@ type Container struct {
 	DB           *gorm.DB
 	Config       *config.Config
 	Repositories map[string]interface{}
+	Storage      storage.BlobStore
+	AvatarCache  *storage.FileCache
+	Verifier     *auth.Verifier
+	Revocations  *auth.RevocationList
+	APIKeys      *auth.APIKeys
 }
 
 func Init() (*Container, error) {
@@ -40,12 +49,45 @@ func Init() (*Container, error) {
 
 	repositories := initRepositories(db)
 
+	store, err := initStorage(cfg)
+	if err != nil {
+		logger.Fatal("Error initializing blob storage:", err)
+		return nil, err
+	}
+
+	revocations := auth.NewRevocationList(
+		repository.NewRevocationRepository(db),
+		cfg.Auth.RevocationCacheTTL,
+	)
+
+	apiKeys := auth.NewAPIKeys(
+		repository.NewAPIKeyRepository(db),
+		cfg.Auth.APIKeyCacheTTL,
+	)
+
+	verifier, err := initAuth(cfg, revocations, apiKeys)
+	if err != nil {
+		logger.Fatal("Error initializing token verification:", err)
+		return nil, err
+	}
+
+	avatarCache, err := storage.NewFileCache(cfg.Avatar.CacheDir)
+	if err != nil {
+		logger.Fatal("Error initializing avatar cache:", err)
+		return nil, err
+	}
+
 	logger.Info("✅ Dependencies initialized successfully")
 
 	return &Container{
 		DB:           db,
 		Config:       cfg,
 		Repositories: repositories,
+		Storage:      store,
+		AvatarCache:  avatarCache,
+		Verifier:     verifier,
+		Revocations:  revocations,
+		APIKeys:      apiKeys,
 	}, nil
 }
 
@@ -61,7 +103,7 @@ func initDatabase(cfg *config.Config) (*gorm.DB, error) {
 		cfg.Postgres.Port,
 	)
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		logger.Fatal("Error connecting to the database:", err)
 		return nil, err
@@ -120,10 +162,67 @@ func initMigrations(sqlDB *sql.DB, logger *logrus.Logger) (*gorm.DB, error, bool
 	return nil, nil, false
 }
 
+func initAuth(cfg *config.Config, revocations *auth.RevocationList, apiKeys *auth.APIKeys) (*auth.Verifier, error) {
+	authCfg := auth.Config{
+		Algorithms:    cfg.Auth.Algorithms,
+		HMACSecret:    []byte(cfg.Auth.JWTSecret),
+		Issuers:       cfg.Auth.Issuers,
+		Audiences:     cfg.Auth.Audiences,
+		Leeway:        cfg.Auth.Leeway,
+		DefaultScopes: cfg.Auth.DefaultScopes,
+		Revocations:   revocations,
+		APIKeys:       apiKeys,
+	}
+
+	if cfg.Auth.JWKSURL != "" {
+		authCfg.Keys = auth.NewKeySource(cfg.Auth.JWKSURL)
+		if err := authCfg.Keys.Refresh(context.Background()); err != nil {
+			return nil, fmt.Errorf("load JWKS: %w", err)
+		}
+	}
+
+	return auth.NewVerifier(authCfg)
+}
+
+func initStorage(cfg *config.Config) (storage.BlobStore, error) {
+	if cfg.Storage.Driver == "s3" {
+		return storage.NewS3Store(storage.S3Config{
+			Endpoint:  cfg.Storage.S3.Endpoint,
+			Region:    cfg.Storage.S3.Region,
+			Bucket:    cfg.Storage.S3.Bucket,
+			AccessKey: cfg.Storage.S3.AccessKey,
+			SecretKey: cfg.Storage.S3.SecretKey,
+			PathStyle: cfg.Storage.S3.PathStyle,
+			PublicURL: cfg.Storage.PublicURL,
+		})
+	}
+
+	signingKey := []byte(cfg.Storage.SigningKey)
+	if len(signingKey) == 0 {
+		// Fine for development: nothing signs URLs for longer than a restart.
+		logging.Instance.Warn("STORAGE_SIGNING_KEY is not set, signed URLs of local storage stop working on restart")
+		signingKey = make([]byte, 32)
+		if _, err := rand.Read(signingKey); err != nil {
+			return nil, err
+		}
+	}
+
+	return storage.NewLocalStore(storage.LocalConfig{
+		Root:           cfg.Storage.LocalDir,
+		BaseURL:        cfg.Storage.PublicURL,
+		SigningKey:     signingKey,
+		PublicPrefixes: cfg.Storage.PublicPrefixes,
+	})
+}
+
 func initRepositories(db *gorm.DB) map[string]interface{} {
 	return map[string]interface{}{
-		"user":     repository.NewUserRepository(db),
-		"follower": repository.NewFollowerRelationRepository(db),
+		"user":             repository.NewUserRepository(db),
+		"follower":         repository.NewFollowerRelationRepository(db),
+		"settings":         repository.NewSettingsRepository(db),
+		"counter":          repository.NewFollowCounterRepository(db),
+		"username_history": repository.NewUsernameHistoryRepository(db),
+		"api_key":          repository.NewAPIKeyRepository(db),
 	}
 }
 
@@ -3,6 +3,9 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 	"user_service/pkg/logging"
 
 	"github.com/spf13/viper"
@@ -16,10 +19,99 @@ type PostgresConfig struct {
 	Port     string
 }
 
+type ReconcileConfig struct {
+	// Interval between background counter reconciliations, zero disables the job.
+	Interval  time.Duration `mapstructure:"interval"`
+	BatchSize int           `mapstructure:"batch_size"`
+}
+
+type UsernameConfig struct {
+	// ChangeCooldown is the minimum time between two username changes of the same user.
+	ChangeCooldown time.Duration `mapstructure:"change_cooldown"`
+	// ReservationPeriod is how long a released username stays reserved for its previous owner.
+	ReservationPeriod time.Duration `mapstructure:"reservation_period"`
+}
+
+type PaginationConfig struct {
+	DefaultPageSize int `mapstructure:"default_page_size"`
+	MaxPageSize     int `mapstructure:"max_page_size"`
+	// CountEstimateThreshold switches unfiltered totals to the pg_class estimate once
+	// the table holds at least this many rows, zero always counts exactly.
+	CountEstimateThreshold int64 `mapstructure:"count_estimate_threshold"`
+}
+
+type S3Config struct {
+	Endpoint  string `mapstructure:"endpoint"`
+	Region    string `mapstructure:"region"`
+	Bucket    string `mapstructure:"bucket"`
+	AccessKey string `mapstructure:"access_key"`
+	SecretKey string `mapstructure:"secret_key"`
+	PathStyle bool   `mapstructure:"path_style"`
+}
+
+type StorageConfig struct {
+	// Driver selects the blob store: "local" or "s3".
+	Driver string `mapstructure:"driver"`
+	// LocalDir is where the local blob store keeps its files.
+	LocalDir string `mapstructure:"local_dir"`
+	// PublicURL is the base URL stored blobs are served from. The server mounts
+	// its path for the local store, for S3 it defaults to the bucket URL.
+	PublicURL string `mapstructure:"public_url"`
+	// SigningKey authenticates signed URLs of the local store. Without it a
+	// random key is used, so signed URLs do not survive a restart.
+	SigningKey string `mapstructure:"signing_key"`
+	// PublicPrefixes lists key prefixes the local store serves without a signature.
+	PublicPrefixes []string `mapstructure:"public_prefixes"`
+	// GCInterval between sweeps for blobs of deleted users, zero disables the job.
+	GCInterval time.Duration `mapstructure:"gc_interval"`
+	S3         S3Config      `mapstructure:"s3"`
+}
+
+type AvatarConfig struct {
+	MaxBytes  int64 `mapstructure:"max_bytes"`
+	MaxPixels int   `mapstructure:"max_pixels"`
+	// CacheDir keeps avatar variants resized on demand.
+	CacheDir string `mapstructure:"cache_dir"`
+	// CacheMaxAge is sent in Cache-Control for served avatars.
+	CacheMaxAge time.Duration `mapstructure:"cache_max_age"`
+}
+
+type AuthConfig struct {
+	// JWTSecret verifies HMAC signed tokens, meant for local development.
+	JWTSecret string `mapstructure:"jwt_secret"`
+	// JWKSURL locates the JWKS document with the keys of asymmetric tokens:
+	// an http(s) URL or a file path.
+	JWKSURL string `mapstructure:"jwks_url"`
+	// JWKSRefreshInterval between reloads of the JWKS document.
+	JWKSRefreshInterval time.Duration `mapstructure:"jwks_refresh_interval"`
+	// Algorithms lists the accepted token signing algorithms.
+	Algorithms []string `mapstructure:"algorithms"`
+	// Issuers and Audiences list the accepted iss and aud claims, empty accepts any.
+	Issuers   []string `mapstructure:"issuers"`
+	Audiences []string `mapstructure:"audiences"`
+	// Leeway tolerates clock skew when checking exp, nbf and iat.
+	Leeway time.Duration `mapstructure:"leeway"`
+	// DefaultScopes are granted to tokens without a scope claim, empty keeps
+	// the built-in end user scopes.
+	DefaultScopes []string `mapstructure:"default_scopes"`
+	// RevocationCacheTTL bounds how long revocations made by other instances
+	// take to be noticed.
+	RevocationCacheTTL time.Duration `mapstructure:"revocation_cache_ttl"`
+	// RevocationPurgeInterval between deletions of expired revoked tokens.
+	RevocationPurgeInterval time.Duration `mapstructure:"revocation_purge_interval"`
+	// APIKeyCacheTTL bounds how long API keys revoked by other instances keep working.
+	APIKeyCacheTTL time.Duration `mapstructure:"api_key_cache_ttl"`
+}
+
 type Config struct {
-	Postgres  PostgresConfig `mapstructure:"postgres"`
-	Port      string         `mapstructure:"port"`
-	JwtSecret string         `mapstructure:"jwt_secret"`
+	Postgres   PostgresConfig   `mapstructure:"postgres"`
+	Port       string           `mapstructure:"port"`
+	Auth       AuthConfig       `mapstructure:"auth"`
+	Reconcile  ReconcileConfig  `mapstructure:"reconcile"`
+	Username   UsernameConfig   `mapstructure:"username"`
+	Pagination PaginationConfig `mapstructure:"pagination"`
+	Storage    StorageConfig    `mapstructure:"storage"`
+	Avatar     AvatarConfig     `mapstructure:"avatar"`
 }
 
 func LoadConfig() (*Config, error) {
@@ -34,18 +126,121 @@ func LoadConfig() (*Config, error) {
 	cfg.Postgres.Host = getEnv("POSTGRES_HOST", "")
 	cfg.Postgres.Port = getEnv("POSTGRES_PORT", "")
 	cfg.Port = getEnv("PORT", "")
-	cfg.JwtSecret = getEnv("JWT_SECRET", "")
 
-	err := validateConfig(cfg)
+	var err error
+	cfg.Auth.JWTSecret = getEnv("JWT_SECRET", "")
+	cfg.Auth.JWKSURL = getEnv("JWKS_URL", "")
+	if cfg.Auth.JWKSRefreshInterval, err = getEnvDuration("JWKS_REFRESH_INTERVAL", 15*time.Minute); err != nil {
+		return &Config{}, err
+	}
+	// Without a JWKS only the development HMAC mode is available.
+	defaultAlgorithms := "HS256"
+	if cfg.Auth.JWKSURL != "" {
+		defaultAlgorithms = "RS256,ES256,EdDSA"
+	}
+	cfg.Auth.Algorithms = getEnvList("JWT_ALGORITHMS", defaultAlgorithms)
+	cfg.Auth.Issuers = getEnvList("JWT_ISSUERS", "")
+	cfg.Auth.Audiences = getEnvList("JWT_AUDIENCES", "")
+	if cfg.Auth.Leeway, err = getEnvDuration("JWT_LEEWAY", 30*time.Second); err != nil {
+		return &Config{}, err
+	}
+	cfg.Auth.DefaultScopes = getEnvList("JWT_DEFAULT_SCOPES", "")
+	if cfg.Auth.RevocationCacheTTL, err = getEnvDuration("REVOCATION_CACHE_TTL", 30*time.Second); err != nil {
+		return &Config{}, err
+	}
+	if cfg.Auth.RevocationPurgeInterval, err = getEnvDuration("REVOCATION_PURGE_INTERVAL", time.Hour); err != nil {
+		return &Config{}, err
+	}
+	if cfg.Auth.APIKeyCacheTTL, err = getEnvDuration("API_KEY_CACHE_TTL", 30*time.Second); err != nil {
+		return &Config{}, err
+	}
+
+	if cfg.Reconcile.Interval, err = getEnvDuration("RECONCILE_INTERVAL", time.Hour); err != nil {
+		return &Config{}, err
+	}
+	if cfg.Reconcile.BatchSize, err = getEnvInt("RECONCILE_BATCH_SIZE", 500); err != nil {
+		return &Config{}, err
+	}
+
+	if cfg.Username.ChangeCooldown, err = getEnvDuration("USERNAME_CHANGE_COOLDOWN", 30*24*time.Hour); err != nil {
+		return &Config{}, err
+	}
+	if cfg.Username.ReservationPeriod, err = getEnvDuration("USERNAME_RESERVATION_PERIOD", 14*24*time.Hour); err != nil {
+		return &Config{}, err
+	}
+
+	if cfg.Pagination.DefaultPageSize, err = getEnvInt("DEFAULT_PAGE_SIZE", 10); err != nil {
+		return &Config{}, err
+	}
+	if cfg.Pagination.MaxPageSize, err = getEnvInt("MAX_PAGE_SIZE", 100); err != nil {
+		return &Config{}, err
+	}
+	threshold, err := getEnvInt("COUNT_ESTIMATE_THRESHOLD", 100000)
+	if err != nil {
+		return &Config{}, err
+	}
+	cfg.Pagination.CountEstimateThreshold = int64(threshold)
+
+	cfg.Storage.Driver = getEnv("STORAGE_DRIVER", "local")
+	cfg.Storage.LocalDir = getEnv("STORAGE_LOCAL_DIR", "./data/blobs")
+	cfg.Storage.SigningKey = getEnv("STORAGE_SIGNING_KEY", "")
+	cfg.Storage.PublicPrefixes = getEnvList("STORAGE_PUBLIC_PREFIXES", "avatars/")
+	if cfg.Storage.Driver == "local" {
+		cfg.Storage.PublicURL = getEnv("STORAGE_PUBLIC_URL", "/files")
+	} else {
+		cfg.Storage.PublicURL = getEnv("STORAGE_PUBLIC_URL", "")
+	}
+	if cfg.Storage.GCInterval, err = getEnvDuration("STORAGE_GC_INTERVAL", 24*time.Hour); err != nil {
+		return &Config{}, err
+	}
+
+	cfg.Storage.S3.Endpoint = getEnv("S3_ENDPOINT", "")
+	cfg.Storage.S3.Region = getEnv("S3_REGION", "us-east-1")
+	cfg.Storage.S3.Bucket = getEnv("S3_BUCKET", "")
+	cfg.Storage.S3.AccessKey = getEnv("S3_ACCESS_KEY", "")
+	cfg.Storage.S3.SecretKey = getEnv("S3_SECRET_KEY", "")
+	if cfg.Storage.S3.PathStyle, err = getEnvBool("S3_PATH_STYLE", true); err != nil {
+		return &Config{}, err
+	}
+
+	maxBytes, err := getEnvInt("AVATAR_MAX_BYTES", 5<<20)
 	if err != nil {
 		return &Config{}, err
 	}
+	cfg.Avatar.MaxBytes = int64(maxBytes)
+	if cfg.Avatar.MaxPixels, err = getEnvInt("AVATAR_MAX_PIXELS", 40_000_000); err != nil {
+		return &Config{}, err
+	}
+	cfg.Avatar.CacheDir = getEnv("AVATAR_CACHE_DIR", "./data/avatar-cache")
+	if cfg.Avatar.CacheMaxAge, err = getEnvDuration("AVATAR_CACHE_MAX_AGE", time.Hour); err != nil {
+		return &Config{}, err
+	}
 
-	logging.Instance.Info(fmt.Sprintf("Loaded config: %+v", cfg))
+	err = validateConfig(cfg)
+	if err != nil {
+		return &Config{}, err
+	}
+
+	logging.Instance.Info(fmt.Sprintf("Loaded config: %+v", cfg.redacted()))
 
 	return cfg, nil
 }
 
+// redacted returns a copy of cfg with its secrets masked, for logging.
+func (cfg Config) redacted() Config {
+	for _, secret := range []*string{
+		&cfg.Postgres.Password,
+		&cfg.Auth.JWTSecret,
+		&cfg.Storage.SigningKey,
+		&cfg.Storage.S3.SecretKey,
+	} {
+		if *secret != "" {
+			*secret = "[redacted]"
+		}
+	}
+	return cfg
+}
+
 func getEnv(key, defaultValue string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
@@ -53,6 +248,53 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvList splits a comma separated variable, dropping empty items.
+func getEnvList(key, defaultValue string) []string {
+	var items []string
+	for _, item := range strings.Split(getEnv(key, defaultValue), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func getEnvInt(key string, defaultValue int) (int, error) {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("environment variable %s must be an integer: %w", key, err)
+	}
+	return parsed, nil
+}
+
+func getEnvBool(key string, defaultValue bool) (bool, error) {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("environment variable %s must be a boolean: %w", key, err)
+	}
+	return parsed, nil
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue, nil
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("environment variable %s must be a duration: %w", key, err)
+	}
+	return parsed, nil
+}
+
 func validateConfig(cfg *Config) error {
 
 	requiredFields := map[string]string{
@@ -60,7 +302,6 @@ func validateConfig(cfg *Config) error {
 		"POSTGRES_PASSWORD": cfg.Postgres.Password,
 		"POSTGRES_DB":       cfg.Postgres.Db,
 		"PORT":              cfg.Port,
-		"JWT_SECRET":        cfg.JwtSecret,
 	}
 
 	for field, value := range requiredFields {
@@ -69,5 +310,53 @@ func validateConfig(cfg *Config) error {
 		}
 	}
 
+	if cfg.Auth.JWTSecret == "" && cfg.Auth.JWKSURL == "" {
+		return fmt.Errorf("either JWT_SECRET or JWKS_URL must be set")
+	}
+	if cfg.Auth.JWKSRefreshInterval < 0 || cfg.Auth.Leeway < 0 {
+		return fmt.Errorf("JWKS_REFRESH_INTERVAL and JWT_LEEWAY must not be negative")
+	}
+	if cfg.Auth.RevocationCacheTTL < 0 || cfg.Auth.RevocationPurgeInterval < 0 {
+		return fmt.Errorf("REVOCATION_CACHE_TTL and REVOCATION_PURGE_INTERVAL must not be negative")
+	}
+	if cfg.Auth.APIKeyCacheTTL < 0 {
+		return fmt.Errorf("API_KEY_CACHE_TTL must not be negative")
+	}
+
+	if cfg.Reconcile.Interval < 0 {
+		return fmt.Errorf("RECONCILE_INTERVAL must not be negative")
+	}
+	if cfg.Reconcile.BatchSize < 1 {
+		return fmt.Errorf("RECONCILE_BATCH_SIZE must be positive")
+	}
+	if cfg.Pagination.DefaultPageSize < 1 || cfg.Pagination.MaxPageSize < cfg.Pagination.DefaultPageSize {
+		return fmt.Errorf("DEFAULT_PAGE_SIZE must be positive and not exceed MAX_PAGE_SIZE")
+	}
+	if cfg.Pagination.CountEstimateThreshold < 0 {
+		return fmt.Errorf("COUNT_ESTIMATE_THRESHOLD must not be negative")
+	}
+	switch cfg.Storage.Driver {
+	case "local":
+	case "s3":
+		if cfg.Storage.S3.Endpoint == "" || cfg.Storage.S3.Bucket == "" ||
+			cfg.Storage.S3.AccessKey == "" || cfg.Storage.S3.SecretKey == "" {
+			return fmt.Errorf("S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY and S3_SECRET_KEY are required for s3 storage")
+		}
+	default:
+		return fmt.Errorf("STORAGE_DRIVER must be local or s3")
+	}
+	if cfg.Storage.GCInterval < 0 {
+		return fmt.Errorf("STORAGE_GC_INTERVAL must not be negative")
+	}
+	if cfg.Avatar.MaxBytes < 1 || cfg.Avatar.MaxPixels < 1 {
+		return fmt.Errorf("AVATAR_MAX_BYTES and AVATAR_MAX_PIXELS must be positive")
+	}
+	if cfg.Avatar.CacheMaxAge < 0 {
+		return fmt.Errorf("AVATAR_CACHE_MAX_AGE must not be negative")
+	}
+	if cfg.Username.ChangeCooldown < 0 || cfg.Username.ReservationPeriod < 0 {
+		return fmt.Errorf("USERNAME_CHANGE_COOLDOWN and USERNAME_RESERVATION_PERIOD must not be negative")
+	}
+
 	return nil
 }
@@ -0,0 +1,60 @@
+package delivery
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"user_service/internal/service"
+	"user_service/internal/transport/request"
+)
+
+type APIKeyHandler struct {
+	s *service.APIKeyService
+}
+
+func NewAPIKeyHandler(s *service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{s: s}
+}
+
+func (h *APIKeyHandler) CreateAPIKey(ctx *gin.Context) {
+	var req request.CreateAPIKeyRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	res, err := h.s.CreateAPIKey(req, userID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, res)
+}
+
+func (h *APIKeyHandler) ListAPIKeys(ctx *gin.Context) {
+	res, err := h.s.ListAPIKeys()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(ctx *gin.Context) {
+	id, ok := idParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.s.RevokeAPIKey(ctx.Request.Context(), id); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+}
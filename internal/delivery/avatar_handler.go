@@ -0,0 +1,135 @@
+package delivery
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"user_service/internal/service"
+)
+
+// multipartOverhead leaves room for the multipart envelope around the avatar file.
+const multipartOverhead = 64 << 10
+
+var errMissingAvatar = service.NewBadRequestError("missing_avatar", "multipart field avatar is required")
+
+type AvatarHandler struct {
+	s *service.AvatarService
+	// cacheMaxAge is how long clients may reuse a served avatar without revalidating.
+	cacheMaxAge time.Duration
+}
+
+func NewAvatarHandler(s *service.AvatarService, cacheMaxAge time.Duration) *AvatarHandler {
+	return &AvatarHandler{s: s, cacheMaxAge: cacheMaxAge}
+}
+
+// ResetMyAvatar replaces the caller's avatar with a freshly generated default one.
+func (h *AvatarHandler) ResetMyAvatar(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	res, err := h.s.ResetAvatar(ctx.Request.Context(), userID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// GetAvatar serves the avatar of a user, resized to the optional size query
+// parameter. Responses carry an ETag and are revalidated with If-None-Match.
+func (h *AvatarHandler) GetAvatar(ctx *gin.Context) {
+	userID, ok := idParam(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	var size int
+	if sizeStr := ctx.Query("size"); sizeStr != "" {
+		var err error
+		if size, err = strconv.Atoi(sizeStr); err != nil {
+			ctx.Error(invalidParam("size"))
+			return
+		}
+	}
+
+	img, err := h.s.GetAvatar(ctx.Request.Context(), userID, size, ifNoneMatch(ctx.GetHeader("If-None-Match")))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("ETag", img.ETag)
+	ctx.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.cacheMaxAge.Seconds())))
+	if img.NotModified {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	ctx.Data(http.StatusOK, img.ContentType, img.Data)
+}
+
+// ifNoneMatch lists the entity tags of an If-None-Match header. The comparison
+// is weak, as RFC 9110 requires for it, so W/ prefixes are dropped.
+func ifNoneMatch(header string) []string {
+	var tags []string
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/"); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func (h *AvatarHandler) UploadMyAvatar(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	maxBytes := h.s.MaxUploadBytes()
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxBytes+multipartOverhead)
+
+	header, err := ctx.FormFile("avatar")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			ctx.Error(service.ErrAvatarTooLarge)
+			return
+		}
+		ctx.Error(errMissingAvatar)
+		return
+	}
+	if header.Size > maxBytes {
+		ctx.Error(service.ErrAvatarTooLarge)
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	res, err := h.s.UploadAvatar(ctx.Request.Context(), userID, data)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,78 @@
+package delivery
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"reflect"
+	"strings"
+	"user_service/internal/service"
+)
+
+// bindJSON decodes the request body into obj and runs its binding rules.
+// Rule violations are recorded as a service.ValidationError listing every failing field.
+func bindJSON(ctx *gin.Context, obj any) bool {
+	return checkBinding(ctx, ctx.ShouldBindJSON(obj), errInvalidBody)
+}
+
+// bindQuery is bindJSON for query string parameters.
+func bindQuery(ctx *gin.Context, obj any) bool {
+	return checkBinding(ctx, ctx.ShouldBindQuery(obj), errInvalidQuery)
+}
+
+// checkBinding records err on ctx, falling back to malformed when the input
+// could not be decoded at all.
+func checkBinding(ctx *gin.Context, err error, malformed error) bool {
+	if err == nil {
+		return true
+	}
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		ctx.Error(malformed)
+		return false
+	}
+
+	fields := make([]service.FieldError, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		fields = append(fields, service.FieldError{
+			Field:   fe.Field(),
+			Code:    fe.Tag(),
+			Message: fieldErrorMessage(fe),
+		})
+	}
+
+	ctx.Error(&service.ValidationError{Fields: fields})
+	return false
+}
+
+func fieldErrorMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters long", fe.Param())
+		}
+		if fe.Kind() == reflect.Slice {
+			return fmt.Sprintf("must have at least %s items", fe.Param())
+		}
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters long", fe.Param())
+		}
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "username":
+		return "may only contain letters, digits, underscores and single dots between them"
+	case "notreserved":
+		return "is reserved"
+	case "http_url":
+		return "must be a valid http or https URL"
+	default:
+		return "is invalid"
+	}
+}
@@ -0,0 +1,44 @@
+package delivery
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+	"user_service/internal/storage"
+)
+
+// BlobHandler serves the files of a local blob store. Keys outside its public
+// prefixes are only served through URLs made by SignedURL.
+type BlobHandler struct {
+	store *storage.LocalStore
+}
+
+func NewBlobHandler(store *storage.LocalStore) *BlobHandler {
+	return &BlobHandler{store: store}
+}
+
+func (h *BlobHandler) ServeBlob(ctx *gin.Context) {
+	key := strings.TrimPrefix(ctx.Param("key"), "/")
+
+	if !h.store.IsPublic(key) {
+		if err := h.store.Verify(key, ctx.Query("expires"), ctx.Query("signature")); err != nil {
+			ctx.Error(err)
+			return
+		}
+	}
+
+	f, err := h.store.Open(key)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	http.ServeContent(ctx.Writer, ctx.Request, info.Name(), info.ModTime(), f)
+}
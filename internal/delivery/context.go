@@ -0,0 +1,118 @@
+package delivery
+
+import (
+	"github.com/gin-gonic/gin"
+	"strconv"
+	"strings"
+	"user_service/internal/auth"
+	"user_service/internal/middleware"
+	"user_service/internal/service"
+)
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+var (
+	errInvalidBody  = service.NewBadRequestError("invalid_body", "invalid request body")
+	errInvalidQuery = service.NewBadRequestError("invalid_query", "invalid query parameters")
+	errUnauthorized = service.NewUnauthorizedError("unauthorized", "you're unauthorized")
+	errUserRequired = service.NewForbiddenError("user_token_required", "this endpoint acts on a user account and needs a user token")
+)
+
+// invalidParam reports an unparsable path or query parameter.
+func invalidParam(name string) error {
+	return service.NewBadRequestError("invalid_parameter", "invalid "+name+" parameter")
+}
+
+// currentPrincipal returns the user authenticated by AuthMiddleware.
+// It records an error on the context and returns false when there is none,
+// including for services authenticated by API key.
+func currentPrincipal(ctx *gin.Context) (*auth.Principal, bool) {
+	principal, ok := middleware.CurrentPrincipal(ctx)
+	if !ok {
+		ctx.Error(errUnauthorized)
+		return nil, false
+	}
+	if principal.UserID == 0 {
+		ctx.Error(errUserRequired)
+		return nil, false
+	}
+	return principal, true
+}
+
+// currentUserID returns the id of the user authenticated by AuthMiddleware.
+// It records an error on the context and returns false when the id is unavailable.
+func currentUserID(ctx *gin.Context) (uint, bool) {
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return 0, false
+	}
+	return principal.UserID, true
+}
+
+// viewer returns the authenticated caller, or nil for anonymous callers.
+func viewer(ctx *gin.Context) *auth.Principal {
+	principal, _ := middleware.CurrentPrincipal(ctx)
+	return principal
+}
+
+// viewerID returns the authenticated user id, or zero for anonymous callers and services.
+func viewerID(ctx *gin.Context) uint {
+	if principal := viewer(ctx); principal != nil {
+		return principal.UserID
+	}
+	return 0
+}
+
+// idParam parses a numeric path parameter.
+// It records an error on the context and returns false when the parameter is invalid.
+func idParam(ctx *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param(name), 10, 32)
+	if err != nil {
+		ctx.Error(invalidParam(name))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// idsQuery parses a comma separated list of up to maxListLimit ids from the
+// query parameter name, dropping duplicates. It records an error on the
+// context and returns false when the list is empty, too long or malformed.
+func idsQuery(ctx *gin.Context, name string) ([]uint, bool) {
+	var ids []uint
+	seen := make(map[uint]bool)
+	for _, item := range strings.Split(ctx.Query(name), ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(item), 10, 32)
+		if err != nil || id == 0 {
+			ctx.Error(invalidParam(name))
+			return nil, false
+		}
+		if !seen[uint(id)] {
+			seen[uint(id)] = true
+			ids = append(ids, uint(id))
+		}
+	}
+	if len(ids) > maxListLimit {
+		ctx.Error(invalidParam(name))
+		return nil, false
+	}
+	return ids, true
+}
+
+// limitQuery parses the optional limit query parameter of cursor paginated lists.
+// It records an error on the context and returns false when the value is out of range.
+func limitQuery(ctx *gin.Context) (int, bool) {
+	limitStr := ctx.Query("limit")
+	if limitStr == "" {
+		return defaultListLimit, true
+	}
+
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 1 || limit > maxListLimit {
+		ctx.Error(invalidParam("limit"))
+		return 0, false
+	}
+	return limit, true
+}
@@ -0,0 +1,238 @@
+package delivery
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"user_service/internal/auth"
+	"user_service/internal/service"
+	"user_service/internal/transport/response"
+)
+
+type FollowHandler struct {
+	s *service.FollowService
+}
+
+func NewFollowHandler(s *service.FollowService) *FollowHandler {
+	return &FollowHandler{s: s}
+}
+
+func (h *FollowHandler) Follow(ctx *gin.Context) {
+	targetID, ok := idParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	res, err := h.s.Follow(userID, targetID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, res)
+}
+
+func (h *FollowHandler) Unfollow(ctx *gin.Context) {
+	targetID, ok := idParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := h.s.Unfollow(userID, targetID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Unfollowed successfully"})
+}
+
+func (h *FollowHandler) ListFollowers(ctx *gin.Context) {
+	userID, ok := idParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	h.listConnections(ctx, userID, h.s.ListFollowers)
+}
+
+func (h *FollowHandler) ListFollowing(ctx *gin.Context) {
+	userID, ok := idParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	h.listConnections(ctx, userID, h.s.ListFollowing)
+}
+
+func (h *FollowHandler) ListMyFollowers(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	h.listConnections(ctx, userID, h.s.ListFollowers)
+}
+
+func (h *FollowHandler) ListMyFollowing(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	h.listConnections(ctx, userID, h.s.ListFollowing)
+}
+
+func (h *FollowHandler) listConnections(
+	ctx *gin.Context,
+	userID uint,
+	list func(userID uint, viewer *auth.Principal, cursor string, limit int) (*response.FollowListResponse, error),
+) {
+	limit, ok := limitQuery(ctx)
+	if !ok {
+		return
+	}
+
+	res, err := list(userID, viewer(ctx), ctx.Query("cursor"), limit)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (h *FollowHandler) Block(ctx *gin.Context) {
+	targetID, ok := idParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	res, err := h.s.Block(userID, targetID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, res)
+}
+
+func (h *FollowHandler) Unblock(ctx *gin.Context) {
+	targetID, ok := idParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := h.s.Unblock(userID, targetID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Unblocked successfully"})
+}
+
+func (h *FollowHandler) IncomingRequests(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	res, err := h.s.IncomingRequests(userID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (h *FollowHandler) OutgoingRequests(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	res, err := h.s.OutgoingRequests(userID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (h *FollowHandler) ApproveRequest(ctx *gin.Context) {
+	requestID, ok := idParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	res, err := h.s.ApproveRequest(userID, requestID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (h *FollowHandler) RejectRequest(ctx *gin.Context) {
+	requestID, ok := idParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := h.s.RejectRequest(userID, requestID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Follow request rejected"})
+}
+
+func (h *FollowHandler) CancelRequest(ctx *gin.Context) {
+	requestID, ok := idParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := h.s.CancelRequest(userID, requestID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Follow request cancelled"})
+}
@@ -0,0 +1,60 @@
+package delivery
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"user_service/internal/service"
+)
+
+type SessionHandler struct {
+	s *service.SessionService
+}
+
+func NewSessionHandler(s *service.SessionService) *SessionHandler {
+	return &SessionHandler{s: s}
+}
+
+// RevokeMySessions logs the caller out everywhere.
+func (h *SessionHandler) RevokeMySessions(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := h.s.RevokeAllSessions(ctx.Request.Context(), userID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "All sessions revoked"})
+}
+
+// RevokeCurrentSession logs out the token used for this request.
+func (h *SessionHandler) RevokeCurrentSession(ctx *gin.Context) {
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+
+	if err := h.s.RevokeCurrentSession(ctx.Request.Context(), principal); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+// RevokeUserSessions forces another user to log out everywhere.
+func (h *SessionHandler) RevokeUserSessions(ctx *gin.Context) {
+	userID, ok := idParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.s.ForceLogout(ctx.Request.Context(), userID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "All sessions of the user revoked"})
+}
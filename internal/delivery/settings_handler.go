@@ -0,0 +1,51 @@
+package delivery
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"user_service/internal/service"
+	"user_service/internal/transport/request"
+)
+
+type SettingsHandler struct {
+	s *service.SettingsService
+}
+
+func NewSettingsHandler(s *service.SettingsService) *SettingsHandler {
+	return &SettingsHandler{s: s}
+}
+
+func (h *SettingsHandler) GetSettings(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	res, err := h.s.GetSettings(userID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (h *SettingsHandler) UpdateSettings(ctx *gin.Context) {
+	var req request.UpdateSettingsRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	res, err := h.s.UpdateSettings(userID, req)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
@@ -1,11 +1,13 @@
 package delivery
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
-	"strconv"
+	"net/url"
 	"user_service/internal/service"
 	"user_service/internal/transport/request"
+	"user_service/internal/transport/response"
 )
 
 type UserHandler struct {
@@ -17,15 +19,50 @@ func NewUserHandler(s *service.UserService) *UserHandler {
 }
 
 func (h *UserHandler) GetUserByID(ctx *gin.Context) {
-	id, err := strconv.Atoi(ctx.Param("id"))
+	id, ok := idParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	res, err := h.s.GetUserByID(id, viewer(ctx))
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, "Invalid id")
+		ctx.Error(err)
 		return
 	}
 
-	res, err := h.s.GetUserByID(uint(id))
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (h *UserHandler) GetUserByUsername(ctx *gin.Context) {
+	res, err := h.s.GetUserByUsername(ctx.Param("username"), viewer(ctx))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, "Could not get user")
+		var moved *service.UsernameMovedError
+		if errors.As(err, &moved) {
+			ctx.Header("Location", "/api/v1/user/by-username/"+url.PathEscape(moved.Username))
+			ctx.JSON(http.StatusTemporaryRedirect, gin.H{
+				"error":    moved.Error(),
+				"code":     "username_moved",
+				"user_id":  moved.UserID,
+				"username": moved.Username,
+			})
+			return
+		}
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (h *UserHandler) SearchUsers(ctx *gin.Context) {
+	limit, ok := limitQuery(ctx)
+	if !ok {
+		return
+	}
+
+	res, err := h.s.SearchUsers(ctx.Query("q"), viewerID(ctx), ctx.Query("cursor"), limit)
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
@@ -34,14 +71,13 @@ func (h *UserHandler) GetUserByID(ctx *gin.Context) {
 
 func (h *UserHandler) CreateUser(ctx *gin.Context) {
 	var req request.CreateUserRequest
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, "Invalid data")
+	if !bindJSON(ctx, &req) {
 		return
 	}
 
-	res, err := h.s.CreateUser(req)
+	res, err := h.s.CreateUser(ctx.Request.Context(), req)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, "Could not create user")
+		ctx.Error(err)
 		return
 	}
 
@@ -49,107 +85,125 @@ func (h *UserHandler) CreateUser(ctx *gin.Context) {
 }
 
 func (h *UserHandler) UpdateUser(ctx *gin.Context) {
-	var req request.UpdateUserRequest
+	userID, ok := idParam(ctx, "id")
+	if !ok {
+		return
+	}
 
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data"})
+	h.updateUser(ctx, userID)
+}
+
+func (h *UserHandler) DeleteUser(ctx *gin.Context) {
+	userID, ok := idParam(ctx, "id")
+	if !ok {
 		return
 	}
 
-	requestUserIdStr := ctx.Param("id")
-	requestUserIdUint64, err := strconv.ParseUint(requestUserIdStr, 10, 32)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid params"})
+	h.deleteUser(ctx, userID)
+}
+
+// GetMe returns the private view of the caller's own profile.
+func (h *UserHandler) GetMe(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
 		return
 	}
-	requestUserId := uint(requestUserIdUint64)
 
-	userIDVal, exists := ctx.Get("user_id")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "You're unauthorized"})
+	res, err := h.s.GetMe(userID)
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
-	userID, ok := userIDVal.(uint)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (h *UserHandler) UpdateMe(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
 	if !ok {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user id in context"})
 		return
 	}
 
-	res, err := h.s.UpdateUser(userID, req, requestUserId)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+	h.updateUser(ctx, userID)
+}
+
+func (h *UserHandler) DeleteMe(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, res)
+	h.deleteUser(ctx, userID)
 }
 
-func (h *UserHandler) DeleteUser(ctx *gin.Context) {
-	requestUserIdStr := ctx.Param("id")
-	requestUserIdUint64, err := strconv.ParseUint(requestUserIdStr, 10, 32)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID parameter"})
+func (h *UserHandler) updateUser(ctx *gin.Context, userID uint) {
+	var req request.UpdateUserRequest
+
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	actor, ok := currentPrincipal(ctx)
+	if !ok {
 		return
 	}
-	requestUserID := uint(requestUserIdUint64)
 
-	userIDVal, exists := ctx.Get("user_id")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	res, err := h.s.UpdateUser(ctx.Request.Context(), actor, userID, req)
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
-	userIDUint64, ok := userIDVal.(uint64)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (h *UserHandler) deleteUser(ctx *gin.Context, userID uint) {
+	actor, ok := currentPrincipal(ctx)
 	if !ok {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID in context"})
 		return
 	}
-	userID := uint(userIDUint64)
 
-	if err := h.s.DeleteUser(userID, requestUserID); err != nil {
-		if err.Error() == "unauthorized: users can only delete their own account" {
-			ctx.JSON(http.StatusForbidden, gin.H{"error": "You can only delete your own account"})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+	if err := h.s.DeleteUser(ctx.Request.Context(), actor, userID); err != nil {
+		ctx.Error(err)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
 }
 
+// GetUsersPaginated lists users page by page, or looks up the users given by
+// the ids query parameter at once.
 func (h *UserHandler) GetUsersPaginated(ctx *gin.Context) {
+	if _, ok := ctx.GetQuery("ids"); ok {
+		h.getUsersByIDs(ctx)
+		return
+	}
 
-	pageStr := ctx.Query("page")
-	pageSizeStr := ctx.Query("page_size")
-
-	page := 1
-	pageSize := 10
-
-	var err error
+	var req request.ListUsersRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
 
-	if pageStr != "" {
-		page, err = strconv.Atoi(pageStr)
-		if err != nil || page < 1 {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page parameter"})
-			return
-		}
+	resp, err := h.s.GetUsersPaginated(req, viewerID(ctx))
+	if err != nil {
+		ctx.Error(err)
+		return
 	}
 
-	if pageSizeStr != "" {
-		pageSize, err = strconv.Atoi(pageSizeStr)
-		if err != nil || pageSize < 1 {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page_size parameter"})
-			return
-		}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (h *UserHandler) getUsersByIDs(ctx *gin.Context) {
+	ids, ok := idsQuery(ctx, "ids")
+	if !ok {
+		return
 	}
 
-	resp, err := h.s.GetUsersPaginated(page, pageSize)
+	users, err := h.s.GetUsersByIDs(ids, viewer(ctx))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, resp)
+	ctx.JSON(http.StatusOK, response.UsersResponse{Users: users})
 }
@@ -1,53 +1,122 @@
 package middleware
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"net/http"
-	"strconv"
 	"strings"
+	"user_service/internal/auth"
 )
 
-func AuthMiddleware(jwtKey string) gin.HandlerFunc {
+// authRealm names the protection space in WWW-Authenticate challenges.
+const authRealm = "user_service"
+
+// principalKey stores the authenticated *auth.Principal in the gin context.
+const principalKey = "principal"
+
+// apiKeyHeader carries the API key of service callers.
+const apiKeyHeader = "X-API-Key"
+
+// AuthMiddleware authenticates users by bearer token and services by API key.
+// A bearer token takes precedence when both are sent.
+func AuthMiddleware(verifier *auth.Verifier) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header missing"})
-			c.Abort()
+		token, ok := bearerToken(c)
+		if ok {
+			authenticate(c, verifier, token)
 			return
 		}
 
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		claims := &jwt.MapClaims{}
+		if key := c.GetHeader(apiKeyHeader); key != "" {
+			authenticateAPIKey(c, verifier, key)
+			return
+		}
 
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return []byte(jwtKey), nil
-		})
+		// RFC 6750 leaves the error out when no credentials were offered.
+		c.Header("WWW-Authenticate", `Bearer realm="`+authRealm+`"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{Error: "Authorization header missing", Code: "missing_token"})
+	}
+}
 
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
+// OptionalAuthMiddleware authenticates the caller when an Authorization or API key header
+// is present and lets anonymous requests through, so public routes can tailor responses to the viewer.
+func OptionalAuthMiddleware(verifier *auth.Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := bearerToken(c)
+		if ok {
+			authenticate(c, verifier, token)
 			return
 		}
 
-		// Extract "sub" as a string
-		userIDStr, okID := (*claims)["sub"].(string)
-		if !okID {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token data, could not get ID"})
+		if key := c.GetHeader(apiKeyHeader); key != "" {
+			authenticateAPIKey(c, verifier, key)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// bearerToken extracts the token of a Bearer Authorization header. Other
+// schemes count as no credentials.
+func bearerToken(c *gin.Context) (string, bool) {
+	scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// authenticate verifies token and stores the principal in the context, or
+// rejects the request with an RFC 6750 invalid_token challenge.
+func authenticate(c *gin.Context, verifier *auth.Verifier, token string) {
+	principal, err := verifier.Verify(c.Request.Context(), token)
+	if err != nil {
+		var authErr *auth.Error
+		if !errors.As(err, &authErr) {
+			c.Error(err)
 			c.Abort()
 			return
 		}
 
-		// Convert the string to uint
-		userID, err := strconv.ParseUint(userIDStr, 10, 32) // 32-bit uint
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID format"})
+		c.Header("WWW-Authenticate", `Bearer realm="`+authRealm+`", error="invalid_token", error_description="`+authErr.Description+`"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{Error: authErr.Description, Code: authErr.Code})
+		return
+	}
+
+	c.Set(principalKey, principal)
+	c.Next()
+}
+
+// authenticateAPIKey verifies key and stores the service principal in the
+// context. API keys are no bearer tokens, so rejections carry a bare challenge.
+func authenticateAPIKey(c *gin.Context, verifier *auth.Verifier, key string) {
+	principal, err := verifier.VerifyAPIKey(c.Request.Context(), key)
+	if err != nil {
+		var authErr *auth.Error
+		if !errors.As(err, &authErr) {
+			c.Error(err)
 			c.Abort()
 			return
 		}
 
-		// Store the user ID as uint in context
-		c.Set("user_id", uint(userID)) // Store as uint
-		c.Next()
+		c.Header("WWW-Authenticate", `Bearer realm="`+authRealm+`"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{Error: authErr.Description, Code: authErr.Code})
+		return
+	}
+
+	c.Set(principalKey, principal)
+	c.Next()
+}
+
+// CurrentPrincipal returns the caller authenticated by AuthMiddleware or
+// OptionalAuthMiddleware, if any.
+func CurrentPrincipal(c *gin.Context) (*auth.Principal, bool) {
+	value, exists := c.Get(principalKey)
+	if !exists {
+		return nil, false
 	}
+	principal, ok := value.(*auth.Principal)
+	return principal, ok
 }
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+	"user_service/internal/auth"
+)
+
+// RequireScope rejects callers whose token does not grant every one of scopes
+// with an RFC 6750 insufficient_scope challenge. It must run after AuthMiddleware.
+func RequireScope(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := requirePrincipal(c)
+		if !ok {
+			return
+		}
+		checkScopes(c, principal, scopes)
+	}
+}
+
+// RequireScopeIfAuthenticated is RequireScope for routes open to anonymous
+// callers, which it lets through. It must run after OptionalAuthMiddleware.
+func RequireScopeIfAuthenticated(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := CurrentPrincipal(c)
+		if !ok {
+			c.Next()
+			return
+		}
+		checkScopes(c, principal, scopes)
+	}
+}
+
+// checkScopes continues the chain when principal holds every one of scopes and
+// aborts with an insufficient_scope challenge otherwise.
+func checkScopes(c *gin.Context, principal *auth.Principal, scopes []string) {
+	for _, scope := range scopes {
+		if !principal.HasScope(scope) {
+			c.Header("WWW-Authenticate", `Bearer realm="`+authRealm+`", error="insufficient_scope", scope="`+strings.Join(scopes, " ")+`"`)
+			c.AbortWithStatusJSON(http.StatusForbidden, ErrorResponse{Error: "token lacks the " + scope + " scope", Code: "insufficient_scope"})
+			return
+		}
+	}
+	c.Next()
+}
+
+// RequireRole rejects callers holding none of roles. It must run after AuthMiddleware.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := requirePrincipal(c)
+		if !ok {
+			return
+		}
+
+		if !principal.HasAnyRole(roles...) {
+			c.AbortWithStatusJSON(http.StatusForbidden, ErrorResponse{Error: "this action requires the " + strings.Join(roles, " or ") + " role", Code: "insufficient_role"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// requirePrincipal returns the authenticated caller, aborting with 401 when the
+// route was mistakenly left without AuthMiddleware or the caller is anonymous.
+func requirePrincipal(c *gin.Context) (*auth.Principal, bool) {
+	principal, ok := CurrentPrincipal(c)
+	if !ok {
+		c.Header("WWW-Authenticate", `Bearer realm="`+authRealm+`"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{Error: "authentication required", Code: "missing_token"})
+		return nil, false
+	}
+	return principal, true
+}
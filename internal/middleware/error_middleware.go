@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"user_service/internal/service"
+	"user_service/internal/storage"
+	"user_service/pkg/logging"
+	"user_service/pkg/pagination"
+)
+
+// ErrorResponse is the body of every error returned by the API.
+type ErrorResponse struct {
+	Error  string               `json:"error"`
+	Code   string               `json:"code"`
+	Fields []service.FieldError `json:"fields,omitempty"`
+}
+
+var errorStatuses = []struct {
+	kind   error
+	status int
+}{
+	{service.ErrNotFound, http.StatusNotFound},
+	{service.ErrConflict, http.StatusConflict},
+	{service.ErrForbidden, http.StatusForbidden},
+	{service.ErrValidation, http.StatusUnprocessableEntity},
+	{service.ErrBadRequest, http.StatusBadRequest},
+	{service.ErrUnauthorized, http.StatusUnauthorized},
+	{service.ErrTooLarge, http.StatusRequestEntityTooLarge},
+	{service.ErrUnsupported, http.StatusUnsupportedMediaType},
+}
+
+// ErrorMiddleware renders the last error attached with ctx.Error by a handler.
+// Domain errors are mapped to their HTTP status, anything else becomes a 500.
+func ErrorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		status, body := mapError(c.Errors.Last().Err)
+		if status == http.StatusInternalServerError {
+			logging.Instance.WithField("path", c.Request.URL.Path).Error(c.Errors.Last().Err)
+		}
+
+		c.AbortWithStatusJSON(status, body)
+	}
+}
+
+func mapError(err error) (int, ErrorResponse) {
+	var validationErr *service.ValidationError
+	if errors.As(err, &validationErr) {
+		return http.StatusUnprocessableEntity, ErrorResponse{
+			Error:  validationErr.Error(),
+			Code:   "validation_failed",
+			Fields: validationErr.Fields,
+		}
+	}
+
+	var domainErr *service.Error
+	if errors.As(err, &domainErr) {
+		for _, es := range errorStatuses {
+			if errors.Is(domainErr.Kind, es.kind) {
+				return es.status, ErrorResponse{Error: domainErr.Message, Code: domainErr.Code}
+			}
+		}
+	}
+
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound, ErrorResponse{Error: "resource not found", Code: "not_found"}
+	case errors.Is(err, pagination.ErrInvalidCursor):
+		return http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: "invalid_cursor"}
+	case errors.Is(err, storage.ErrNotFound):
+		return http.StatusNotFound, ErrorResponse{Error: "resource not found", Code: "not_found"}
+	case errors.Is(err, storage.ErrInvalidSignature):
+		return http.StatusForbidden, ErrorResponse{Error: err.Error(), Code: "invalid_signature"}
+	}
+
+	return http.StatusInternalServerError, ErrorResponse{Error: "internal server error", Code: "internal_error"}
+}
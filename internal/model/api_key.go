@@ -0,0 +1,25 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// APIKey authenticates another service. Only the SHA-256 hash of the key is
+// stored; Prefix keeps its first characters so admins can tell keys apart.
+type APIKey struct {
+	ID         uint `gorm:"primaryKey"`
+	Name       string
+	Prefix     string
+	KeyHash    string
+	Scopes     string // space separated
+	CreatedBy  uint
+	CreatedAt  time.Time
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+func (k *APIKey) ScopeList() []string {
+	return strings.Fields(k.Scopes)
+}
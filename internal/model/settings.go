@@ -8,3 +8,11 @@ type Settings struct {
 	IsPrivate bool `gorm:"default:false"`
 	DarkMode  bool `gorm:"default:false"`
 }
+
+// DefaultSettings returns the settings every new user starts with.
+func DefaultSettings() Settings {
+	return Settings{
+		IsPrivate: false,
+		DarkMode:  false,
+	}
+}
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// RevokedToken blocks a single token by its jti until it expires anyway.
+type RevokedToken struct {
+	JTI       string `gorm:"column:jti;primaryKey"`
+	UserID    uint
+	ExpiresAt time.Time
+	RevokedAt time.Time
+}
+
+// TokenCutoff invalidates every token of a user issued before RevokedBefore.
+// There is no foreign key to users: the cutoff must outlive deleted accounts.
+type TokenCutoff struct {
+	UserID        uint `gorm:"primaryKey"`
+	RevokedBefore time.Time
+}
@@ -11,7 +11,7 @@ type User struct {
 	Bio            string
 	FollowersCount uint               `gorm:"default:0"`
 	FollowingCount uint               `gorm:"default:0"`
-	Followers      []FollowerRelation `gorm:"foreignKey:UserID" json:"omitempty"`
-	Following      []FollowerRelation `gorm:"foreignKey:FollowerID" json:"omitempty"`
-	Settings       Settings           `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;" json:"omitempty"`
+	Followers      []FollowerRelation `gorm:"foreignKey:UserID" json:"followers,omitempty"`
+	Following      []FollowerRelation `gorm:"foreignKey:FollowerID" json:"following,omitempty"`
+	Settings       Settings           `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;" json:"settings,omitempty"`
 }
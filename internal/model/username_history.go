@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// UsernameHistory records a username change. Until ReservedUntil the old
+// handle can only be taken back by the same user.
+type UsernameHistory struct {
+	ID            uint `gorm:"primaryKey"`
+	UserID        uint
+	OldUsername   string
+	NewUsername   string
+	ChangedAt     time.Time
+	ReservedUntil time.Time
+}
+
+func (UsernameHistory) TableName() string {
+	return "username_history"
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"time"
+	"user_service/internal/model"
+)
+
+type APIKeyRepository interface {
+	Create(key *model.APIKey) error
+	FindAPIKey(hash string) (*model.APIKey, error)
+	List() ([]model.APIKey, error)
+	Revoke(id uint, at time.Time) (bool, error)
+	TouchAPIKey(id uint, at time.Time) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) Create(key *model.APIKey) error {
+	return translateError(r.db.Create(key).Error)
+}
+
+// FindAPIKey returns the key with the given hash, or nil when there is none.
+// Revoked and expired keys are returned too, callers decide how to reject them.
+func (r *apiKeyRepository) FindAPIKey(hash string) (*model.APIKey, error) {
+	var key model.APIKey
+	err := r.db.Where("key_hash = ?", hash).First(&key).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// List returns every key, revoked ones included, oldest first.
+func (r *apiKeyRepository) List() ([]model.APIKey, error) {
+	var keys []model.APIKey
+	err := r.db.Order("id").Find(&keys).Error
+	return keys, err
+}
+
+// Revoke marks the key revoked at the given time. It reports false when no
+// active key has that id.
+func (r *apiKeyRepository) Revoke(id uint, at time.Time) (bool, error) {
+	res := r.db.Model(&model.APIKey{}).Where("id = ? AND revoked_at IS NULL", id).Update("revoked_at", at)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *apiKeyRepository) TouchAPIKey(id uint, at time.Time) error {
+	return r.db.Model(&model.APIKey{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
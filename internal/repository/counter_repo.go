@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"user_service/internal/model"
+)
+
+type FollowCounterRepository interface {
+	ReconcileBatch(afterID uint, limit int) (lastID uint, fixed int64, err error)
+}
+
+type followCounterRepository struct {
+	db *gorm.DB
+}
+
+func NewFollowCounterRepository(db *gorm.DB) FollowCounterRepository {
+	return &followCounterRepository{db: db}
+}
+
+// ReconcileBatch recomputes followers_count and following_count for up to limit users
+// with an id greater than afterID. Only approved relations whose other side is an active
+// user are counted. It returns the last id of the batch (zero once there are no users
+// left) and the number of rows whose counters were corrected.
+func (r *followCounterRepository) ReconcileBatch(afterID uint, limit int) (uint, int64, error) {
+	var ids []uint
+	var fixed int64
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the batch in ascending id order, as AdjustFollowCounts does, before
+		// counting. Counted inside the UPDATE, the relations would come from its
+		// snapshot and overwrite adjustments committed while it waited for a row.
+		err := tx.Model(&model.User{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id > ?", afterID).Order("id").Limit(limit).Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		res := tx.Exec(`
+			UPDATE users u
+			SET followers_count = c.followers, following_count = c.following
+			FROM (
+				SELECT b.id,
+					(SELECT COUNT(*) FROM follower_relations fr
+						JOIN users f ON f.id = fr.follower_id AND f.deleted_at IS NULL
+						WHERE fr.user_id = b.id AND fr.status = ?) AS followers,
+					(SELECT COUNT(*) FROM follower_relations fr
+						JOIN users t ON t.id = fr.user_id AND t.deleted_at IS NULL
+						WHERE fr.follower_id = b.id AND fr.status = ?) AS following
+				FROM users b
+				WHERE b.id IN ?
+			) c
+			WHERE u.id = c.id
+				AND (u.followers_count IS DISTINCT FROM c.followers OR u.following_count IS DISTINCT FROM c.following)`,
+			model.StatusApproved, model.StatusApproved, ids,
+		)
+		fixed = res.RowsAffected
+		return res.Error
+	})
+	if err != nil || len(ids) == 0 {
+		return 0, 0, err
+	}
+
+	return ids[len(ids)-1], fixed, nil
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrDuplicate is returned when a write violates a unique constraint.
+var ErrDuplicate = errors.New("duplicate key")
+
+// translateError maps driver level errors onto repository errors.
+func translateError(err error) error {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrDuplicate
+	}
+	return err
+}
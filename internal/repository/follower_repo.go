@@ -2,20 +2,43 @@ package repository
 
 import (
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
 	"user_service/internal/model" // update this import path based on your project structure
+	"user_service/pkg/pagination"
 )
 
+// FollowListEntry is a user on one side of an approved relation, together with the
+// relation's keyset position.
+type FollowListEntry struct {
+	ID                uint
+	Username          string
+	AvatarURL         string
+	RelationID        uint
+	RelationCreatedAt time.Time
+}
+
 type FollowerRelationRepository interface {
 	Create(relation *model.FollowerRelation) error
 	UpdateStatus(id uint, status string) error
 	GetByID(id uint) (*model.FollowerRelation, error)
-	ListFollowers(userID uint) ([]model.FollowerRelation, error)
-	ListFollowing(followerID uint) ([]model.FollowerRelation, error)
+	GetByPair(userID, followerID uint) (*model.FollowerRelation, error)
+	ListFollowers(userID uint, after *pagination.Cursor, limit int) ([]FollowListEntry, error)
+	ListFollowing(followerID uint, after *pagination.Cursor, limit int) ([]FollowListEntry, error)
+	ListPendingIncoming(userID uint) ([]model.FollowerRelation, error)
+	ListPendingOutgoing(followerID uint) ([]model.FollowerRelation, error)
+	CountPendingIncoming(userID uint) (int64, error)
+	CountPendingOutgoing(followerID uint) (int64, error)
 	Delete(id uint) error
+	IsBlocked(blockerID, blockedID uint) (bool, error)
+	AdjustFollowCounts(userID, followerID uint, delta int) error
+	Transaction(fn func(tx FollowerRelationRepository) error) error
 }
 
 type followerRelationRepository struct {
 	db *gorm.DB
+	// lock makes single-row reads take a row lock; set for transaction-bound repositories.
+	lock bool
 }
 
 func NewFollowerRelationRepository(db *gorm.DB) FollowerRelationRepository {
@@ -23,7 +46,7 @@ func NewFollowerRelationRepository(db *gorm.DB) FollowerRelationRepository {
 }
 
 func (r *followerRelationRepository) Create(relation *model.FollowerRelation) error {
-	return r.db.Create(relation).Error
+	return translateError(r.db.Create(relation).Error)
 }
 
 func (r *followerRelationRepository) UpdateStatus(id uint, status string) error {
@@ -32,25 +55,129 @@ func (r *followerRelationRepository) UpdateStatus(id uint, status string) error
 
 func (r *followerRelationRepository) GetByID(id uint) (*model.FollowerRelation, error) {
 	var relation model.FollowerRelation
-	err := r.db.First(&relation, id).Error
+	err := r.read().First(&relation, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &relation, nil
+}
+
+func (r *followerRelationRepository) GetByPair(userID, followerID uint) (*model.FollowerRelation, error) {
+	var relation model.FollowerRelation
+	err := r.read().Where("user_id = ? AND follower_id = ?", userID, followerID).First(&relation).Error
 	if err != nil {
 		return nil, err
 	}
 	return &relation, nil
 }
 
-func (r *followerRelationRepository) ListFollowers(userID uint) ([]model.FollowerRelation, error) {
+// ListFollowers returns approved followers of userID, newest first, starting after the cursor.
+func (r *followerRelationRepository) ListFollowers(userID uint, after *pagination.Cursor, limit int) ([]FollowListEntry, error) {
+	return r.listApproved("user_id", "follower_id", userID, after, limit)
+}
+
+// ListFollowing returns users followerID follows with approval, newest first, starting after the cursor.
+func (r *followerRelationRepository) ListFollowing(followerID uint, after *pagination.Cursor, limit int) ([]FollowListEntry, error) {
+	return r.listApproved("follower_id", "user_id", followerID, after, limit)
+}
+
+func (r *followerRelationRepository) listApproved(ownerColumn, otherColumn string, ownerID uint, after *pagination.Cursor, limit int) ([]FollowListEntry, error) {
+	query := r.db.Table("follower_relations fr").
+		Select("u.id, u.username, u.avatar_url, fr.id AS relation_id, fr.created_at AS relation_created_at").
+		Joins("JOIN users u ON u.id = fr."+otherColumn+" AND u.deleted_at IS NULL").
+		Where("fr."+ownerColumn+" = ? AND fr.status = ?", ownerID, model.StatusApproved)
+
+	if after != nil {
+		createdAt, err := time.Parse(time.RFC3339Nano, after.Key)
+		if err != nil {
+			return nil, pagination.ErrInvalidCursor
+		}
+		query = query.Where("(fr.created_at, fr.id) < (?, ?)", createdAt, after.ID)
+	}
+
+	var entries []FollowListEntry
+	err := query.Order("fr.created_at DESC, fr.id DESC").Limit(limit).Scan(&entries).Error
+	return entries, err
+}
+
+func (r *followerRelationRepository) ListPendingIncoming(userID uint) ([]model.FollowerRelation, error) {
 	var relations []model.FollowerRelation
-	err := r.db.Where("user_id = ?", userID).Find(&relations).Error
+	err := r.db.Where("user_id = ? AND status = ?", userID, model.StatusPending).
+		Order("created_at DESC").Find(&relations).Error
 	return relations, err
 }
 
-func (r *followerRelationRepository) ListFollowing(followerID uint) ([]model.FollowerRelation, error) {
+func (r *followerRelationRepository) ListPendingOutgoing(followerID uint) ([]model.FollowerRelation, error) {
 	var relations []model.FollowerRelation
-	err := r.db.Where("follower_id = ?", followerID).Find(&relations).Error
+	err := r.db.Where("follower_id = ? AND status = ?", followerID, model.StatusPending).
+		Order("created_at DESC").Find(&relations).Error
 	return relations, err
 }
 
+func (r *followerRelationRepository) CountPendingIncoming(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.FollowerRelation{}).
+		Where("user_id = ? AND status = ?", userID, model.StatusPending).Count(&count).Error
+	return count, err
+}
+
+func (r *followerRelationRepository) CountPendingOutgoing(followerID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.FollowerRelation{}).
+		Where("follower_id = ? AND status = ?", followerID, model.StatusPending).Count(&count).Error
+	return count, err
+}
+
 func (r *followerRelationRepository) Delete(id uint) error {
 	return r.db.Delete(&model.FollowerRelation{}, id).Error
 }
+
+// IsBlocked reports whether blockerID has blocked blockedID.
+func (r *followerRelationRepository) IsBlocked(blockerID, blockedID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.FollowerRelation{}).
+		Where("user_id = ? AND follower_id = ? AND status = ?", blockerID, blockedID, model.StatusBlocked).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// AdjustFollowCounts shifts users.followers_count of userID and users.following_count
+// of followerID by delta, never going below zero. The rows are updated in ascending id
+// order, so two users following each other at once cannot deadlock on their row locks.
+func (r *followerRelationRepository) AdjustFollowCounts(userID, followerID uint, delta int) error {
+	updates := []struct {
+		id     uint
+		column string
+	}{
+		{userID, "followers_count"},
+		{followerID, "following_count"},
+	}
+	if followerID < userID {
+		updates[0], updates[1] = updates[1], updates[0]
+	}
+
+	for _, u := range updates {
+		err := r.db.Model(&model.User{}).Where("id = ?", u.id).
+			Update(u.column, gorm.Expr("GREATEST("+u.column+" + ?, 0)", delta)).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Transaction runs fn against a repository bound to a single database transaction.
+// GetByID and GetByPair lock the returned row until the transaction ends, so status
+// checks and counter updates made inside fn cannot race with concurrent changes.
+func (r *followerRelationRepository) Transaction(fn func(tx FollowerRelationRepository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&followerRelationRepository{db: tx, lock: true})
+	})
+}
+
+func (r *followerRelationRepository) read() *gorm.DB {
+	if r.lock {
+		return r.db.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	return r.db
+}
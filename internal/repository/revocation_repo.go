@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+	"user_service/internal/model"
+)
+
+type RevocationRepository interface {
+	RevokeToken(jti string, userID uint, expiresAt time.Time) error
+	IsTokenRevoked(jti string) (bool, error)
+	RevokeUserTokens(userID uint, before time.Time) error
+	UserTokensRevokedBefore(userID uint) (time.Time, error)
+	DeleteExpiredTokens(before time.Time) (int64, error)
+}
+
+type revocationRepository struct {
+	db *gorm.DB
+}
+
+func NewRevocationRepository(db *gorm.DB) RevocationRepository {
+	return &revocationRepository{db: db}
+}
+
+// RevokeToken records jti as revoked. Revoking it again is a no-op.
+func (r *revocationRepository) RevokeToken(jti string, userID uint, expiresAt time.Time) error {
+	token := model.RevokedToken{JTI: jti, UserID: userID, ExpiresAt: expiresAt, RevokedAt: time.Now()}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&token).Error
+}
+
+func (r *revocationRepository) IsTokenRevoked(jti string) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.RevokedToken{}).Where("jti = ?", jti).Limit(1).Count(&count).Error
+	return count > 0, err
+}
+
+// RevokeUserTokens moves the cutoff of userID forward to before. An existing
+// later cutoff is kept.
+func (r *revocationRepository) RevokeUserTokens(userID uint, before time.Time) error {
+	cutoff := model.TokenCutoff{UserID: userID, RevokedBefore: before}
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"revoked_before": gorm.Expr("GREATEST(token_cutoffs.revoked_before, EXCLUDED.revoked_before)"),
+		}),
+	}).Create(&cutoff).Error
+}
+
+// UserTokensRevokedBefore returns the cutoff of userID, or the zero time when
+// none of their tokens were revoked.
+func (r *revocationRepository) UserTokensRevokedBefore(userID uint) (time.Time, error) {
+	var cutoff model.TokenCutoff
+	err := r.db.Where("user_id = ?", userID).First(&cutoff).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, nil
+	}
+	return cutoff.RevokedBefore, err
+}
+
+// DeleteExpiredTokens forgets revoked tokens that expired before the given time
+// and are rejected by their exp claim alone.
+func (r *revocationRepository) DeleteExpiredTokens(before time.Time) (int64, error) {
+	res := r.db.Where("expires_at < ?", before).Delete(&model.RevokedToken{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"user_service/internal/model"
+)
+
+type SettingsRepository interface {
+	GetByUserID(userID uint) (*model.Settings, error)
+	Create(settings *model.Settings) error
+	Update(settings *model.Settings) error
+}
+
+type settingsRepository struct {
+	db *gorm.DB
+}
+
+func NewSettingsRepository(db *gorm.DB) SettingsRepository {
+	return &settingsRepository{db: db}
+}
+
+func (r *settingsRepository) GetByUserID(userID uint) (*model.Settings, error) {
+	var settings model.Settings
+	err := r.db.Where("user_id = ?", userID).First(&settings).Error
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (r *settingsRepository) Create(settings *model.Settings) error {
+	return translateError(r.db.Create(settings).Error)
+}
+
+func (r *settingsRepository) Update(settings *model.Settings) error {
+	return r.db.Model(settings).Select("IsPrivate", "DarkMode", "UpdatedAt").Updates(settings).Error
+}
@@ -2,9 +2,20 @@ package repository
 
 import (
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strconv"
+	"strings"
+	"time"
 	"user_service/internal/model"
+	"user_service/pkg/pagination"
 )
 
+// SearchExactKey marks a search cursor placed right after the exact handle match,
+// which is always ranked first regardless of its followers count.
+const SearchExactKey = "exact"
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 // UserRepositoryImpl is the implementation of UserRepository
 type UserRepositoryImpl struct {
 	db *gorm.DB
@@ -15,9 +26,18 @@ func NewUserRepository(db *gorm.DB) *UserRepositoryImpl {
 	return &UserRepositoryImpl{db: db}
 }
 
-// CreateUser creates a new user in the database.
+// CreateUser creates a new user in the database together with user.Settings.
+// A username clash with another active user is reported as ErrDuplicate.
 func (r *UserRepositoryImpl) CreateUser(user *model.User) error {
-	return r.db.Create(user).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Settings").Create(user).Error; err != nil {
+			return translateError(err)
+		}
+
+		user.Settings.UserID = user.ID
+		// Select keeps false values that would otherwise be skipped as zero.
+		return tx.Select("UserID", "IsPrivate", "DarkMode").Create(&user.Settings).Error
+	})
 }
 
 // GetUserByID fetches a user by their ID.
@@ -29,32 +49,244 @@ func (r *UserRepositoryImpl) GetUserByID(id uint) (*model.User, error) {
 	return &user, nil
 }
 
-// GetUserByUsername fetches a user by their username.
+// GetUsersByIDs fetches the users among ids that are not deleted.
+func (r *UserRepositoryImpl) GetUsersByIDs(ids []uint) ([]model.User, error) {
+	var users []model.User
+	err := r.db.Where("id IN ?", ids).Find(&users).Error
+	return users, err
+}
+
+// GetUserByUsername fetches a user by their username, ignoring case.
 func (r *UserRepositoryImpl) GetUserByUsername(username string) (*model.User, error) {
 	var user model.User
-	if err := r.db.Where("username = ?", username).First(&user).Error; err != nil {
+	if err := r.db.Where("LOWER(username) = LOWER(?)", username).First(&user).Error; err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
 // UpdateUser updates the user record in the database.
+// A username clash with another active user is reported as ErrDuplicate.
 func (r *UserRepositoryImpl) UpdateUser(user *model.User) error {
-	return r.db.Save(user).Error
+	return translateError(r.db.Save(user).Error)
 }
 
-// DeleteUser deletes a user from the database.
+// RenameUser saves user with its new username and records the change in the same transaction.
+func (r *UserRepositoryImpl) RenameUser(user *model.User, entry *model.UsernameHistory) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(user).Error; err != nil {
+			return translateError(err)
+		}
+		return tx.Create(entry).Error
+	})
+}
+
+// DeleteUser deletes a user from the database together with their follower relations.
+// Counters of the users on the other side of approved relations are decremented in the same transaction.
 func (r *UserRepositoryImpl) DeleteUser(id uint) error {
-	return r.db.Delete(&model.User{}, id).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		followed := tx.Model(&model.FollowerRelation{}).Select("user_id").
+			Where("follower_id = ? AND status = ?", id, model.StatusApproved)
+		err := tx.Model(&model.User{}).Where("id IN (?)", followed).
+			Update("followers_count", gorm.Expr("GREATEST(followers_count - 1, 0)")).Error
+		if err != nil {
+			return err
+		}
+
+		followers := tx.Model(&model.FollowerRelation{}).Select("follower_id").
+			Where("user_id = ? AND status = ?", id, model.StatusApproved)
+		err = tx.Model(&model.User{}).Where("id IN (?)", followers).
+			Update("following_count", gorm.Expr("GREATEST(following_count - 1, 0)")).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("user_id = ? OR follower_id = ?", id, id).Delete(&model.FollowerRelation{}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Delete(&model.User{}, id).Error
+	})
+}
+
+// ActiveUserIDs returns those of ids that belong to users which are not deleted.
+func (r *UserRepositoryImpl) ActiveUserIDs(ids []uint) ([]uint, error) {
+	var active []uint
+	err := r.db.Model(&model.User{}).Where("id IN ?", ids).Pluck("id", &active).Error
+	return active, err
 }
 
-// GetUsersPaginated retrieves users with pagination for infinite scrolling.
-func (r *UserRepositoryImpl) GetUsersPaginated(page, pageSize int) ([]model.User, error) {
+// UserListQuery filters and orders the user listing.
+type UserListQuery struct {
+	Page     int
+	PageSize int
+	// Sort is one of the UserSort* columns, Desc reverses its natural ascending order.
+	Sort           string
+	Desc           bool
+	UsernamePrefix string
+	CreatedAfter   *time.Time
+	CreatedBefore  *time.Time
+	MinFollowers   *uint
+	// After switches to keyset pagination: rows strictly after the cursor are returned
+	// and Page is ignored.
+	After *pagination.Cursor
+	// ViewerID hides users who blocked the viewer, zero for anonymous callers.
+	ViewerID uint
+}
+
+const (
+	UserSortCreatedAt      = "created_at"
+	UserSortUsername       = "username"
+	UserSortFollowersCount = "followers_count"
+)
+
+// HasFilters reports whether the query narrows the listing beyond hiding blockers.
+func (q UserListQuery) HasFilters() bool {
+	return q.UsernamePrefix != "" || q.CreatedAfter != nil || q.CreatedBefore != nil || q.MinFollowers != nil
+}
+
+// GetUsersPaginated retrieves one page of users. It reads one row past the page
+// so callers can tell whether another page follows.
+func (r *UserRepositoryImpl) GetUsersPaginated(q UserListQuery) ([]model.User, error) {
 	var users []model.User
-	offset := (page - 1) * pageSize
+	offset := (q.Page - 1) * q.PageSize
+
+	// Only whitelisted columns reach ORDER BY.
+	sort := q.Sort
+	switch sort {
+	case UserSortUsername, UserSortFollowersCount:
+	default:
+		sort = UserSortCreatedAt
+	}
+
+	direction := "ASC"
+	if q.Desc {
+		direction = "DESC"
+	}
+
+	query := r.filteredUsers(q)
+	if q.After != nil {
+		key, err := userSortKey(sort, q.After.Key)
+		if err != nil {
+			return nil, pagination.ErrInvalidCursor
+		}
+
+		op := ">"
+		if q.Desc {
+			op = "<"
+		}
+		query = query.Where("("+sort+", id) "+op+" (?, ?)", key, q.After.ID)
+	} else {
+		query = query.Offset(offset)
+	}
 
-	if err := r.db.Limit(pageSize).Offset(offset).Find(&users).Error; err != nil {
+	err := query.
+		Order(sort + " " + direction).Order("id " + direction).
+		Limit(q.PageSize + 1).Find(&users).Error
+	if err != nil {
 		return nil, err
 	}
 	return users, nil
 }
+
+// userSortKey parses a cursor key written for the sort column.
+func userSortKey(sort, key string) (any, error) {
+	switch sort {
+	case UserSortUsername:
+		return key, nil
+	case UserSortFollowersCount:
+		return strconv.ParseUint(key, 10, 64)
+	default:
+		return time.Parse(time.RFC3339Nano, key)
+	}
+}
+
+// CountUsers returns the exact number of users matching q.
+func (r *UserRepositoryImpl) CountUsers(q UserListQuery) (int64, error) {
+	var total int64
+	err := r.filteredUsers(q).Count(&total).Error
+	return total, err
+}
+
+// EstimateUserCount reads the planner's row estimate for the users table, which is
+// far cheaper than COUNT(*) on large tables. reltuples includes soft-deleted users,
+// so it is scaled by the share of NULL deleted_at values from the same statistics.
+// It returns -1 when the table was never analyzed.
+func (r *UserRepositoryImpl) EstimateUserCount() (int64, error) {
+	var estimate int64
+	err := r.db.Raw(`
+		SELECT (c.reltuples * COALESCE(s.null_frac, 1))::bigint
+		FROM pg_class c
+		LEFT JOIN pg_stats s
+			ON s.schemaname = c.relnamespace::regnamespace::text
+			AND s.tablename = c.relname
+			AND s.attname = 'deleted_at'
+		WHERE c.oid = 'users'::regclass`).Scan(&estimate).Error
+	return estimate, err
+}
+
+func (r *UserRepositoryImpl) filteredUsers(q UserListQuery) *gorm.DB {
+	query := r.db.Model(&model.User{})
+
+	if q.ViewerID != 0 {
+		query = query.Where(
+			"id NOT IN (?)",
+			r.db.Model(&model.FollowerRelation{}).Select("user_id").
+				Where("follower_id = ? AND status = ?", q.ViewerID, model.StatusBlocked),
+		)
+	}
+	if q.UsernamePrefix != "" {
+		query = query.Where("LOWER(username) LIKE ?", likeEscaper.Replace(strings.ToLower(q.UsernamePrefix))+"%")
+	}
+	if q.CreatedAfter != nil {
+		query = query.Where("created_at > ?", *q.CreatedAfter)
+	}
+	if q.CreatedBefore != nil {
+		query = query.Where("created_at < ?", *q.CreatedBefore)
+	}
+	if q.MinFollowers != nil {
+		query = query.Where("followers_count >= ?", *q.MinFollowers)
+	}
+
+	return query
+}
+
+// SearchUsers finds users whose username starts with query or whose bio matches it
+// by full-text or trigram word similarity. The exact username match is ranked first,
+// the rest by followers_count and id, both descending. Users who blocked viewerID are left out.
+func (r *UserRepositoryImpl) SearchUsers(query string, viewerID uint, after *pagination.Cursor, limit int) ([]model.User, error) {
+	q := r.db.Model(&model.User{}).
+		Where(
+			"LOWER(username) LIKE ? OR to_tsvector('simple', COALESCE(bio, '')) @@ plainto_tsquery('simple', ?) OR ? <% COALESCE(bio, '')",
+			likeEscaper.Replace(strings.ToLower(query))+"%", query, query,
+		)
+
+	if viewerID != 0 {
+		q = q.Where(
+			"id NOT IN (?)",
+			r.db.Model(&model.FollowerRelation{}).Select("user_id").
+				Where("follower_id = ? AND status = ?", viewerID, model.StatusBlocked),
+		)
+	}
+
+	if after != nil {
+		// The exact match was on an earlier page, continue with the ranked rest.
+		q = q.Where("LOWER(username) <> LOWER(?)", query)
+		if after.Key != SearchExactKey {
+			followers, err := strconv.ParseUint(after.Key, 10, 64)
+			if err != nil {
+				return nil, pagination.ErrInvalidCursor
+			}
+			q = q.Where("(followers_count, id) < (?, ?)", followers, after.ID)
+		}
+	}
+
+	var users []model.User
+	err := q.Order(clause.OrderBy{Expression: clause.Expr{
+		SQL:                "LOWER(username) = LOWER(?) DESC, followers_count DESC, id DESC",
+		Vars:               []interface{}{query},
+		WithoutParentheses: true,
+	}}).Limit(limit).Find(&users).Error
+	return users, err
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"time"
+	"user_service/internal/model"
+)
+
+type UsernameHistoryRepository interface {
+	LastChange(userID uint) (*model.UsernameHistory, error)
+	ActiveReservation(username string, now time.Time) (*model.UsernameHistory, error)
+}
+
+type usernameHistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewUsernameHistoryRepository(db *gorm.DB) UsernameHistoryRepository {
+	return &usernameHistoryRepository{db: db}
+}
+
+// LastChange returns the most recent username change of userID.
+func (r *usernameHistoryRepository) LastChange(userID uint) (*model.UsernameHistory, error) {
+	var entry model.UsernameHistory
+	err := r.db.Where("user_id = ?", userID).Order("changed_at DESC").First(&entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// ActiveReservation returns the latest change that released username and is still reserved at now.
+func (r *usernameHistoryRepository) ActiveReservation(username string, now time.Time) (*model.UsernameHistory, error) {
+	var entry model.UsernameHistory
+	err := r.db.Where("LOWER(old_username) = LOWER(?) AND reserved_until > ?", username, now).
+		Order("reserved_until DESC").First(&entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"user_service/internal/auth"
+	"user_service/internal/bootstrap"
+	"user_service/internal/delivery"
+	"user_service/internal/middleware"
+	"user_service/internal/repository"
+	"user_service/internal/service"
+)
+
+// SetupAdminRoutes registers the administration endpoints, open to admins only.
+func SetupAdminRoutes(router *gin.Engine, bs *bootstrap.Container) {
+
+	kh := delivery.NewAPIKeyHandler(service.NewAPIKeyService(
+		getRepository[repository.APIKeyRepository](bs, "api_key"),
+		bs.APIKeys,
+	))
+
+	adminRoutes := router.Group("/api/v1/admin")
+	adminRoutes.Use(middleware.AuthMiddleware(bs.Verifier), middleware.RequireRole(auth.RoleAdmin))
+	{
+		adminRoutes.POST("/api-keys", kh.CreateAPIKey)
+		adminRoutes.GET("/api-keys", kh.ListAPIKeys)
+		adminRoutes.DELETE("/api-keys/:id", kh.RevokeAPIKey)
+	}
+}
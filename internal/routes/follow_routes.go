@@ -0,0 +1,53 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"user_service/internal/auth"
+	"user_service/internal/bootstrap"
+	"user_service/internal/delivery"
+	"user_service/internal/middleware"
+	"user_service/internal/repository"
+	"user_service/internal/service"
+)
+
+func SetupFollowRoutes(router *gin.Engine, bs *bootstrap.Container) {
+
+	s := newFollowService(bs)
+	h := delivery.NewFollowHandler(s)
+
+	publicRoutes := router.Group("/api/v1/user")
+	publicRoutes.Use(middleware.OptionalAuthMiddleware(bs.Verifier))
+	{
+		// Anonymous callers may read too, authenticated ones need the scope.
+		readFollows := middleware.RequireScopeIfAuthenticated(auth.ScopeFollowsRead)
+
+		publicRoutes.GET("/:id/followers", readFollows, h.ListFollowers)
+		publicRoutes.GET("/:id/following", readFollows, h.ListFollowing)
+	}
+
+	followRoutes := router.Group("/api/v1/user")
+	followRoutes.Use(middleware.AuthMiddleware(bs.Verifier))
+	{
+		readFollows := middleware.RequireScope(auth.ScopeFollowsRead)
+		writeFollows := middleware.RequireScope(auth.ScopeFollowsWrite)
+
+		followRoutes.POST("/:id/follow", writeFollows, h.Follow)
+		followRoutes.DELETE("/:id/follow", writeFollows, h.Unfollow)
+		followRoutes.POST("/:id/block", writeFollows, h.Block)
+		followRoutes.DELETE("/:id/block", writeFollows, h.Unblock)
+
+		followRoutes.GET("/follow-requests", readFollows, h.IncomingRequests)
+		followRoutes.GET("/follow-requests/outgoing", readFollows, h.OutgoingRequests)
+		followRoutes.POST("/follow-requests/:id/approve", writeFollows, h.ApproveRequest)
+		followRoutes.POST("/follow-requests/:id/reject", writeFollows, h.RejectRequest)
+		followRoutes.DELETE("/follow-requests/:id", writeFollows, h.CancelRequest)
+	}
+}
+
+func newFollowService(bs *bootstrap.Container) *service.FollowService {
+	return service.NewFollowService(
+		getRepository[repository.FollowerRelationRepository](bs, "follower"),
+		getRepository[*repository.UserRepositoryImpl](bs, "user"),
+		getRepository[repository.SettingsRepository](bs, "settings"),
+	)
+}
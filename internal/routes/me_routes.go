@@ -0,0 +1,69 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"user_service/internal/auth"
+	"user_service/internal/bootstrap"
+	"user_service/internal/delivery"
+	"user_service/internal/middleware"
+	"user_service/internal/repository"
+	"user_service/internal/service"
+)
+
+// SetupMeRoutes registers the /me family, which resolves the target user from the token subject.
+func SetupMeRoutes(router *gin.Engine, bs *bootstrap.Container) {
+
+	uh := delivery.NewUserHandler(newUserService(bs))
+	fh := delivery.NewFollowHandler(newFollowService(bs))
+	sh := delivery.NewSettingsHandler(service.NewSettingsService(
+		getRepository[repository.SettingsRepository](bs, "settings"),
+	))
+
+	ah := delivery.NewAvatarHandler(newAvatarService(bs), bs.Config.Avatar.CacheMaxAge)
+	sessh := delivery.NewSessionHandler(newSessionService(bs))
+
+	meRoutes := router.Group("/api/v1/user/me")
+	meRoutes.Use(middleware.AuthMiddleware(bs.Verifier))
+	{
+		readUsers := middleware.RequireScope(auth.ScopeUsersRead)
+		writeUsers := middleware.RequireScope(auth.ScopeUsersWrite)
+		readFollows := middleware.RequireScope(auth.ScopeFollowsRead)
+
+		meRoutes.GET("", readUsers, uh.GetMe)
+		meRoutes.PUT("", writeUsers, uh.UpdateMe)
+		meRoutes.DELETE("", writeUsers, uh.DeleteMe)
+		meRoutes.PUT("/avatar", writeUsers, ah.UploadMyAvatar)
+		meRoutes.POST("/avatar/default", writeUsers, ah.ResetMyAvatar)
+
+		meRoutes.GET("/settings", readUsers, sh.GetSettings)
+		meRoutes.PATCH("/settings", writeUsers, sh.UpdateSettings)
+
+		meRoutes.GET("/followers", readFollows, fh.ListMyFollowers)
+		meRoutes.GET("/following", readFollows, fh.ListMyFollowing)
+		meRoutes.GET("/follow-requests", readFollows, fh.IncomingRequests)
+		meRoutes.GET("/follow-requests/outgoing", readFollows, fh.OutgoingRequests)
+
+		// Logging out needs no scope: any token may be used to revoke itself.
+		meRoutes.DELETE("/sessions", sessh.RevokeMySessions)
+		meRoutes.DELETE("/sessions/current", sessh.RevokeCurrentSession)
+	}
+}
+
+func newSessionService(bs *bootstrap.Container) *service.SessionService {
+	return service.NewSessionService(
+		getRepository[*repository.UserRepositoryImpl](bs, "user"),
+		bs.Revocations,
+	)
+}
+
+func newAvatarService(bs *bootstrap.Container) *service.AvatarService {
+	return service.NewAvatarService(
+		getRepository[*repository.UserRepositoryImpl](bs, "user"),
+		bs.Storage,
+		bs.AvatarCache,
+		service.AvatarPolicy{
+			MaxBytes:  bs.Config.Avatar.MaxBytes,
+			MaxPixels: bs.Config.Avatar.MaxPixels,
+		},
+	)
+}
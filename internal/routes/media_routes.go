@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"user_service/internal/bootstrap"
+	"user_service/internal/delivery"
+)
+
+// SetupMediaRoutes registers public, cacheable media endpoints.
+func SetupMediaRoutes(router *gin.Engine, bs *bootstrap.Container) {
+
+	ah := delivery.NewAvatarHandler(newAvatarService(bs), bs.Config.Avatar.CacheMaxAge)
+
+	mediaRoutes := router.Group("/media")
+	{
+		mediaRoutes.GET("/avatars/:user_id", ah.GetAvatar)
+	}
+}
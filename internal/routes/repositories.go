@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"user_service/internal/bootstrap"
+	"user_service/pkg/logging"
+)
+
+// getRepository fetches a repository from the container and asserts its type.
+// Failures are logged and yield the zero value, matching how route setup treats missing dependencies.
+func getRepository[T any](bs *bootstrap.Container, name string) T {
+	var zero T
+
+	ri, err := bs.GetRepository(name)
+	if err != nil {
+		logging.Instance.Error(err)
+		return zero
+	}
+
+	r, ok := ri.(T)
+	if !ok {
+		logging.Instance.Errorf("repository %s has unexpected type %T", name, ri)
+		return zero
+	}
+
+	return r
+}
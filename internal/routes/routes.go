@@ -3,8 +3,19 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	"user_service/internal/bootstrap"
+	"user_service/internal/transport/request"
+	"user_service/pkg/logging"
 )
 
 func SetupRoutes(r *gin.Engine, bs *bootstrap.Container) {
+	if err := request.RegisterValidators(); err != nil {
+		logging.Instance.Fatal("Error registering request validators:", err)
+	}
+
 	SetupUserRoutes(r, bs)
+	SetupFollowRoutes(r, bs)
+	SetupMeRoutes(r, bs)
+	SetupStorageRoutes(r, bs)
+	SetupMediaRoutes(r, bs)
+	SetupAdminRoutes(r, bs)
 }
@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/url"
+	"strings"
+	"user_service/internal/bootstrap"
+	"user_service/internal/delivery"
+	"user_service/internal/storage"
+	"user_service/pkg/logging"
+)
+
+// SetupStorageRoutes serves the files of the local blob store under the path of
+// the configured public URL. Other stores serve their blobs themselves.
+func SetupStorageRoutes(router *gin.Engine, bs *bootstrap.Container) {
+	local, ok := bs.Storage.(*storage.LocalStore)
+	if !ok {
+		return
+	}
+
+	publicURL, err := url.Parse(bs.Config.Storage.PublicURL)
+	if err != nil || strings.Trim(publicURL.Path, "/") == "" {
+		logging.Instance.Fatal("STORAGE_PUBLIC_URL must have a path to serve local blobs from")
+	}
+
+	h := delivery.NewBlobHandler(local)
+	router.GET(strings.TrimSuffix(publicURL.Path, "/")+"/*key", h.ServeBlob)
+}
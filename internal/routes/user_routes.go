@@ -2,42 +2,63 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	"user_service/internal/auth"
 	"user_service/internal/bootstrap"
 	"user_service/internal/delivery"
 	"user_service/internal/middleware"
 	"user_service/internal/repository"
 	"user_service/internal/service"
-	"user_service/pkg/logging"
 )
 
 func SetupUserRoutes(router *gin.Engine, bs *bootstrap.Container) {
 
-	ri, err := bs.GetRepository("user")
-	if err != nil {
-		logging.Instance.Error(err)
-	}
-
-	r, ok := ri.(*repository.UserRepositoryImpl)
-	if !ok {
-		logging.Instance.Error(err)
-	}
-
-	s := service.NewUserService(r)
+	s := newUserService(bs)
 	h := delivery.NewUserHandler(s)
 
 	userRoutes := router.Group("/api/v1/user")
 
 	publicRoutes := userRoutes.Group("/")
+	publicRoutes.Use(middleware.OptionalAuthMiddleware(bs.Verifier))
 	{
+		// Anonymous callers may read too, authenticated ones need the scope.
+		readUsers := middleware.RequireScopeIfAuthenticated(auth.ScopeUsersRead)
+
 		publicRoutes.POST("/", h.CreateUser)
-		publicRoutes.GET("/", h.GetUsersPaginated)
-		publicRoutes.GET("/:id", h.GetUserByID)
+		publicRoutes.GET("/", readUsers, h.GetUsersPaginated)
+		publicRoutes.GET("/search", readUsers, h.SearchUsers)
+		publicRoutes.GET("/:id", readUsers, h.GetUserByID)
+		publicRoutes.GET("/by-username/:username", readUsers, h.GetUserByUsername)
 	}
 
 	privateRoutes := userRoutes.Group("/")
-	privateRoutes.Use(middleware.AuthMiddleware(bs.Config.JwtSecret))
+	privateRoutes.Use(middleware.AuthMiddleware(bs.Verifier), middleware.RequireScope(auth.ScopeUsersWrite))
 	{
+		// Acting on another account additionally needs a privileged role, which
+		// the service checks since it depends on the target.
 		privateRoutes.PUT("/:id", h.UpdateUser)
 		privateRoutes.DELETE("/:id", h.DeleteUser)
+
+		sh := delivery.NewSessionHandler(newSessionService(bs))
+		privateRoutes.DELETE("/:id/sessions", middleware.RequireRole(auth.RoleAdmin, auth.RoleModerator), sh.RevokeUserSessions)
 	}
 }
+
+func newUserService(bs *bootstrap.Container) *service.UserService {
+	return service.NewUserService(
+		getRepository[*repository.UserRepositoryImpl](bs, "user"),
+		getRepository[repository.FollowerRelationRepository](bs, "follower"),
+		getRepository[repository.SettingsRepository](bs, "settings"),
+		getRepository[repository.UsernameHistoryRepository](bs, "username_history"),
+		bs.Storage,
+		bs.Revocations,
+		service.UsernamePolicy{
+			ChangeCooldown:    bs.Config.Username.ChangeCooldown,
+			ReservationPeriod: bs.Config.Username.ReservationPeriod,
+		},
+		service.PaginationPolicy{
+			DefaultPageSize:        bs.Config.Pagination.DefaultPageSize,
+			MaxPageSize:            bs.Config.Pagination.MaxPageSize,
+			CountEstimateThreshold: bs.Config.Pagination.CountEstimateThreshold,
+		},
+	)
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+	"user_service/internal/auth"
+	"user_service/internal/model"
+	"user_service/internal/repository"
+	"user_service/internal/transport/request"
+	"user_service/internal/transport/response"
+)
+
+var (
+	ErrAPIKeyNotFound     = newError(ErrNotFound, "api_key_not_found", "no active API key with this id")
+	ErrAPIKeyExpiryPassed = newError(ErrValidation, "api_key_expiry_passed", "expires_at must be in the future")
+)
+
+// APIKeyService manages the API keys of internal services.
+type APIKeyService struct {
+	repo repository.APIKeyRepository
+	keys *auth.APIKeys
+}
+
+func NewAPIKeyService(repo repository.APIKeyRepository, keys *auth.APIKeys) *APIKeyService {
+	return &APIKeyService{repo: repo, keys: keys}
+}
+
+// CreateAPIKey issues a key on behalf of the admin createdBy. The response is
+// the only place the key appears in clear.
+func (s *APIKeyService) CreateAPIKey(req request.CreateAPIKeyRequest, createdBy uint) (*response.CreatedAPIKeyResponse, error) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, ErrAPIKeyExpiryPassed
+	}
+
+	rawKey, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	key := &model.APIKey{
+		Name:      req.Name,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scopes:    strings.Join(req.Scopes, " "),
+		CreatedBy: createdBy,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := s.repo.Create(key); err != nil {
+		return nil, err
+	}
+
+	return &response.CreatedAPIKeyResponse{APIKeyResponse: *toAPIKeyResponse(key), Key: rawKey}, nil
+}
+
+func (s *APIKeyService) ListAPIKeys() (*response.APIKeyListResponse, error) {
+	keys, err := s.repo.List()
+	if err != nil {
+		return nil, err
+	}
+
+	res := &response.APIKeyListResponse{Keys: make([]response.APIKeyResponse, 0, len(keys))}
+	for i := range keys {
+		res.Keys = append(res.Keys, *toAPIKeyResponse(&keys[i]))
+	}
+	return res, nil
+}
+
+// RevokeAPIKey disables key id. The row is kept so the key shows up as revoked.
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, id uint) error {
+	revoked, err := s.repo.Revoke(id, time.Now())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrAPIKeyNotFound
+	}
+
+	s.keys.Forget(id)
+	return nil
+}
+
+func toAPIKeyResponse(key *model.APIKey) *response.APIKeyResponse {
+	return &response.APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.ScopeList(),
+		CreatedBy:  key.CreatedBy,
+		CreatedAt:  key.CreatedAt,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
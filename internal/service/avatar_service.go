@@ -0,0 +1,367 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"user_service/internal/model"
+	"user_service/internal/storage"
+	"user_service/internal/transport/response"
+	"user_service/pkg/identicon"
+	"user_service/pkg/imaging"
+	"user_service/pkg/logging"
+)
+
+var (
+	ErrAvatarTooLarge    = newError(ErrTooLarge, "avatar_too_large", "avatar file is too large")
+	ErrAvatarType        = newError(ErrUnsupported, "unsupported_avatar_type", "avatar must be a JPEG, PNG, GIF or WebP image")
+	ErrAvatarDimensions  = newError(ErrValidation, "avatar_dimensions", "avatar image dimensions are too large")
+	ErrAvatarUnreadable  = newError(ErrValidation, "avatar_unreadable", "avatar image could not be decoded")
+	ErrAvatarSize        = newError(ErrValidation, "invalid_avatar_size", "avatar size must be between 1 and 1024 pixels")
+	allowedAvatarTypes   = map[string]bool{"image/jpeg": true, "image/png": true, "image/gif": true, "image/webp": true}
+	avatarRenditionSizes = []int{64, 256, 512}
+)
+
+const (
+	// AvatarOriginal names the largest stored rendition, kept for resizing on demand.
+	AvatarOriginal     = "orig"
+	avatarOriginalSide = 1024
+	avatarDefaultSize  = 256
+	avatarJPEGQuality  = 85
+	// defaultAvatarSide is the size of the stored PNG of a generated default avatar.
+	defaultAvatarSide = 512
+	// Sizes without a stored rendition are rounded up to a multiple of
+	// avatarSizeStep, which bounds the number of cached variants per avatar.
+	avatarSizeStep = 32
+)
+
+// AvatarPolicy limits accepted avatar uploads.
+type AvatarPolicy struct {
+	MaxBytes  int64
+	MaxPixels int
+}
+
+type AvatarService struct {
+	users  UserRepository
+	store  storage.BlobStore
+	cache  *storage.FileCache
+	policy AvatarPolicy
+}
+
+func NewAvatarService(users UserRepository, store storage.BlobStore, cache *storage.FileCache, policy AvatarPolicy) *AvatarService {
+	return &AvatarService{users: users, store: store, cache: cache, policy: policy}
+}
+
+// AvatarImage is an avatar ready to be sent to a client. Data is nil when
+// NotModified is set.
+type AvatarImage struct {
+	ETag        string
+	ContentType string
+	Data        []byte
+	NotModified bool
+}
+
+// MaxUploadBytes is the largest avatar file accepted by UploadAvatar.
+func (s *AvatarService) MaxUploadBytes() int64 {
+	return s.policy.MaxBytes
+}
+
+// avatarKeyPrefix starts the storage keys of every user's avatar renditions.
+const avatarKeyPrefix = "avatars/"
+
+// AvatarKey is the storage key of one avatar rendition, size being a pixel size or AvatarOriginal.
+func AvatarKey(userID uint, size string) string {
+	return fmt.Sprintf("%s%d/%s.jpg", avatarKeyPrefix, userID, size)
+}
+
+// UploadAvatar replaces the avatar of userID. The image is cropped to a centered
+// square and re-encoded as JPEG in every rendition size, which also drops EXIF
+// metadata. AvatarURL points at the default rendition with a content version so
+// clients do not keep showing a cached previous avatar.
+func (s *AvatarService) UploadAvatar(ctx context.Context, userID uint, data []byte) (*response.UserResponseFull, error) {
+	if int64(len(data)) > s.policy.MaxBytes {
+		return nil, ErrAvatarTooLarge
+	}
+	if !allowedAvatarTypes[http.DetectContentType(data)] {
+		return nil, ErrAvatarType
+	}
+
+	img, err := imaging.Decode(data, s.policy.MaxPixels)
+	if err != nil {
+		if errors.Is(err, imaging.ErrTooManyPixels) {
+			return nil, ErrAvatarDimensions
+		}
+		return nil, ErrAvatarUnreadable
+	}
+
+	user, err := s.users.GetUserByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	renditions, err := avatarRenditions(img, imaging.Orientation(data))
+	if err != nil {
+		return nil, err
+	}
+
+	for size, content := range renditions {
+		if err := s.store.Put(ctx, AvatarKey(userID, size), bytes.NewReader(content), "image/jpeg"); err != nil {
+			return nil, err
+		}
+	}
+
+	sum := sha256.Sum256(renditions[AvatarOriginal])
+	user.AvatarURL = s.storedAvatarURL(userID) + "?v=" + hex.EncodeToString(sum[:6])
+	if err := s.users.UpdateUser(user); err != nil {
+		return nil, err
+	}
+
+	// Variants are cached per version, so the old ones are merely unreachable.
+	if err := s.cache.DeleteDir(fmt.Sprint(userID)); err != nil {
+		logging.Instance.WithField("user_id", userID).Warn("Clearing cached avatar variants failed: ", err)
+	}
+
+	return toUserResponseFull(user), nil
+}
+
+// ResetAvatar drops the uploaded avatar of userID, if any, and regenerates the
+// default one from the current username.
+func (s *AvatarService) ResetAvatar(ctx context.Context, userID uint) (*response.UserResponseFull, error) {
+	user, err := s.users.GetUserByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	if err := storeDefaultAvatar(ctx, s.store, user); err != nil {
+		return nil, err
+	}
+	if err := s.users.UpdateUser(user); err != nil {
+		return nil, err
+	}
+
+	for _, size := range append([]string{AvatarOriginal}, renditionNames()...) {
+		if err := s.store.Delete(ctx, AvatarKey(userID, size)); err != nil {
+			logging.Instance.WithField("user_id", userID).Warn("Deleting uploaded avatar failed: ", err)
+		}
+	}
+	if err := s.cache.DeleteDir(fmt.Sprint(userID)); err != nil {
+		logging.Instance.WithField("user_id", userID).Warn("Clearing cached avatar variants failed: ", err)
+	}
+
+	return toUserResponseFull(user), nil
+}
+
+// GetAvatar returns the avatar of userID at size pixels, zero meaning the default
+// size. Users without an uploaded avatar get their identicon, as do users whose
+// AvatarURL points elsewhere: redirecting there would make the route an open
+// redirect. When one of knownETags, or "*", matches the current version the
+// image is not loaded at all.
+func (s *AvatarService) GetAvatar(ctx context.Context, userID uint, size int, knownETags []string) (*AvatarImage, error) {
+	if size == 0 {
+		size = avatarDefaultSize
+	}
+	if size < 1 || size > avatarOriginalSide {
+		return nil, ErrAvatarSize
+	}
+	if !slices.Contains(avatarRenditionSizes, size) {
+		size = (size + avatarSizeStep - 1) / avatarSizeStep * avatarSizeStep
+	}
+
+	user, err := s.users.GetUserByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	version, stored := s.storedAvatarVersion(user)
+	if !stored {
+		img := &AvatarImage{ETag: identiconETag(user.Username, size), ContentType: "image/png"}
+		if etagKnown(knownETags, img.ETag) {
+			img.NotModified = true
+			return img, nil
+		}
+
+		if img.Data, err = s.identiconVariant(user, size); err != nil {
+			return nil, err
+		}
+		return img, nil
+	}
+
+	img := &AvatarImage{ETag: fmt.Sprintf(`"%s-%d"`, version, size), ContentType: "image/jpeg"}
+	if etagKnown(knownETags, img.ETag) {
+		img.NotModified = true
+		return img, nil
+	}
+
+	if img.Data, err = s.avatarVariant(ctx, userID, version, size); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// avatarVariant loads a stored rendition, or renders and caches one from the original.
+func (s *AvatarService) avatarVariant(ctx context.Context, userID uint, version string, size int) ([]byte, error) {
+	if slices.Contains(avatarRenditionSizes, size) {
+		return s.readBlob(ctx, AvatarKey(userID, fmt.Sprint(size)))
+	}
+
+	cacheKey := fmt.Sprintf("%d/%s/%d.jpg", userID, version, size)
+	if data, err := s.cache.Get(cacheKey); err == nil {
+		return data, nil
+	}
+
+	original, err := s.readBlob(ctx, AvatarKey(userID, AvatarOriginal))
+	if err != nil {
+		return nil, err
+	}
+	img, err := jpeg.Decode(bytes.NewReader(original))
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := imaging.EncodeJPEG(&buf, imaging.Resize(img, size, size), avatarJPEGQuality); err != nil {
+		return nil, err
+	}
+
+	if err := s.cache.Put(cacheKey, buf.Bytes()); err != nil {
+		logging.Instance.WithField("user_id", userID).Warn("Caching avatar variant failed: ", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// identiconVariant renders the identicon of user as PNG, cached like the resized
+// uploads so that requests do not pay for a render and encode each time.
+func (s *AvatarService) identiconVariant(user *model.User, size int) ([]byte, error) {
+	cacheKey := fmt.Sprintf("%d/%s/%d.png", user.ID, identiconVersion(user.Username), size)
+	if data, err := s.cache.Get(cacheKey); err == nil {
+		return data, nil
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, identicon.New(user.Username).Image(size)); err != nil {
+		return nil, err
+	}
+
+	if err := s.cache.Put(cacheKey, buf.Bytes()); err != nil {
+		logging.Instance.WithField("user_id", user.ID).Warn("Caching identicon failed: ", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *AvatarService) readBlob(ctx context.Context, key string) ([]byte, error) {
+	rc, err := s.store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// storedAvatarURL is the AvatarURL of an uploaded avatar without its version.
+func (s *AvatarService) storedAvatarURL(userID uint) string {
+	return s.store.URL(AvatarKey(userID, fmt.Sprint(avatarDefaultSize)))
+}
+
+// storedAvatarVersion reports whether user's AvatarURL points at an uploaded
+// avatar and returns its version.
+func (s *AvatarService) storedAvatarVersion(user *model.User) (string, bool) {
+	version, ok := strings.CutPrefix(user.AvatarURL, s.storedAvatarURL(user.ID)+"?v=")
+	return version, ok && version != ""
+}
+
+// defaultAvatarKey is the storage key of the generated default avatar of userID
+// in the given format, png or svg.
+func defaultAvatarKey(userID uint, format string) string {
+	return fmt.Sprintf("%s%d/default.%s", avatarKeyPrefix, userID, format)
+}
+
+// storeDefaultAvatar stores the identicon of user as PNG and SVG and points
+// user.AvatarURL at the PNG. Persisting the user is left to the caller.
+func storeDefaultAvatar(ctx context.Context, store storage.BlobStore, user *model.User) error {
+	icon := identicon.New(user.Username)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, icon.Image(defaultAvatarSide)); err != nil {
+		return err
+	}
+	if err := store.Put(ctx, defaultAvatarKey(user.ID, "png"), &buf, "image/png"); err != nil {
+		return err
+	}
+	svg := bytes.NewReader(icon.SVG(defaultAvatarSide))
+	if err := store.Put(ctx, defaultAvatarKey(user.ID, "svg"), svg, "image/svg+xml"); err != nil {
+		return err
+	}
+
+	user.AvatarURL = store.URL(defaultAvatarKey(user.ID, "png")) + "?v=" + identiconVersion(user.Username)
+	return nil
+}
+
+// isDefaultAvatar reports whether user.AvatarURL points at their generated default avatar.
+func isDefaultAvatar(store storage.BlobStore, user *model.User) bool {
+	return strings.HasPrefix(user.AvatarURL, store.URL(defaultAvatarKey(user.ID, "png"))+"?v=")
+}
+
+func renditionNames() []string {
+	names := make([]string, 0, len(avatarRenditionSizes))
+	for _, size := range avatarRenditionSizes {
+		names = append(names, fmt.Sprint(size))
+	}
+	return names
+}
+
+func etagKnown(known []string, etag string) bool {
+	return slices.Contains(known, etag) || slices.Contains(known, "*")
+}
+
+// identiconVersion changes whenever the identicon of username would.
+func identiconVersion(username string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(username)))
+	return "i" + hex.EncodeToString(sum[:6])
+}
+
+func identiconETag(username string, size int) string {
+	return fmt.Sprintf(`"%s-%d"`, identiconVersion(username), size)
+}
+
+// avatarRenditions encodes the original and every rendition size of img as JPEG.
+func avatarRenditions(img image.Image, orientation int) (map[string][]byte, error) {
+	square := imaging.CropSquare(img)
+	side := min(square.Bounds().Dx(), avatarOriginalSide)
+	original := imaging.Orient(imaging.Resize(square, side, side), orientation)
+
+	renditions := make(map[string]image.Image, len(avatarRenditionSizes)+1)
+	renditions[AvatarOriginal] = original
+	for _, size := range avatarRenditionSizes {
+		renditions[fmt.Sprint(size)] = imaging.Resize(original, size, size)
+	}
+
+	encoded := make(map[string][]byte, len(renditions))
+	for name, rendition := range renditions {
+		var buf bytes.Buffer
+		if err := imaging.EncodeJPEG(&buf, rendition, avatarJPEGQuality); err != nil {
+			return nil, err
+		}
+		encoded[name] = buf.Bytes()
+	}
+	return encoded, nil
+}
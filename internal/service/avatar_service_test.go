@@ -0,0 +1,70 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"testing"
+	"user_service/internal/storage"
+)
+
+func newTestAvatarService(t *testing.T, users *fakeUsers) *AvatarService {
+	cache, err := storage.NewFileCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewAvatarService(users, newMemStore(), cache, AvatarPolicy{MaxBytes: 1 << 20, MaxPixels: 1 << 20})
+}
+
+func TestGetAvatarForeignURL(t *testing.T) {
+	user := testUser(7, "bob")
+	user.AvatarURL = "https://evil.example/phish"
+	s := newTestAvatarService(t, newFakeUsers(user))
+
+	img, err := s.GetAvatar(context.Background(), 7, 64, nil)
+	if err != nil {
+		t.Fatalf("GetAvatar: %v", err)
+	}
+	if img.ContentType != "image/png" || img.ETag != identiconETag("bob", 64) {
+		t.Fatalf("got %s with ETag %s, want the identicon", img.ContentType, img.ETag)
+	}
+	decoded, err := png.Decode(bytes.NewReader(img.Data))
+	if err != nil {
+		t.Fatalf("decode identicon: %v", err)
+	}
+	if got := decoded.Bounds().Size(); got != image.Pt(64, 64) {
+		t.Errorf("identicon size %v", got)
+	}
+}
+
+func TestGetAvatarCachesIdenticon(t *testing.T) {
+	s := newTestAvatarService(t, newFakeUsers(testUser(7, "bob")))
+
+	first, err := s.GetAvatar(context.Background(), 7, 100, nil)
+	if err != nil {
+		t.Fatalf("GetAvatar: %v", err)
+	}
+
+	// Sizes are rounded up to the cached variant.
+	key := fmt.Sprintf("7/%s/128.png", identiconVersion("bob"))
+	cached, err := s.cache.Get(key)
+	if err != nil {
+		t.Fatalf("identicon not cached under %s: %v", key, err)
+	}
+	if !bytes.Equal(cached, first.Data) {
+		t.Error("cached identicon differs from the served one")
+	}
+
+	if err := s.cache.Put(key, []byte("cached")); err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.GetAvatar(context.Background(), 7, 128, nil)
+	if err != nil {
+		t.Fatalf("GetAvatar: %v", err)
+	}
+	if string(second.Data) != "cached" {
+		t.Error("identicon rendered again instead of served from the cache")
+	}
+}
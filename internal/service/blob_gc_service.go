@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"user_service/internal/storage"
+	"user_service/pkg/logging"
+)
+
+// blobOwnerBatchSize is how many distinct owners are looked up at once.
+const blobOwnerBatchSize = 500
+
+// BlobCollector deletes stored media of users that were deleted or never existed,
+// e.g. when the cleanup in UserService.DeleteUser failed.
+type BlobCollector struct {
+	users UserRepository
+	store storage.BlobStore
+}
+
+func NewBlobCollector(users UserRepository, store storage.BlobStore) *BlobCollector {
+	return &BlobCollector{users: users, store: store}
+}
+
+// Collect walks all avatar blobs and returns how many orphaned ones were deleted.
+func (c *BlobCollector) Collect(ctx context.Context) (int, error) {
+	var deleted int
+	batch := make(map[uint][]string)
+
+	flush := func() error {
+		n, err := c.deleteOrphans(ctx, batch)
+		deleted += n
+		clear(batch)
+		return err
+	}
+
+	err := c.store.Walk(ctx, avatarKeyPrefix, func(key string) error {
+		userID, ok := blobOwner(key)
+		if !ok {
+			return nil
+		}
+
+		if _, seen := batch[userID]; !seen && len(batch) >= blobOwnerBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		batch[userID] = append(batch[userID], key)
+		return nil
+	})
+	if err != nil {
+		return deleted, err
+	}
+
+	err = flush()
+	return deleted, err
+}
+
+// Run collects every interval until ctx is cancelled.
+func (c *BlobCollector) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := c.Collect(ctx)
+			if err != nil {
+				logging.Instance.Error("Orphaned media collection failed: ", err)
+				continue
+			}
+			logging.Instance.Infof("Orphaned media collection deleted %d blobs", deleted)
+		}
+	}
+}
+
+func (c *BlobCollector) deleteOrphans(ctx context.Context, batch map[uint][]string) (int, error) {
+	if len(batch) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]uint, 0, len(batch))
+	for id := range batch {
+		ids = append(ids, id)
+	}
+
+	active, err := c.users.ActiveUserIDs(ids)
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range active {
+		delete(batch, id)
+	}
+
+	var deleted int
+	for _, keys := range batch {
+		for _, key := range keys {
+			if err := c.store.Delete(ctx, key); err != nil {
+				return deleted, err
+			}
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// deleteUserBlobs removes every stored blob of userID and returns how many were deleted.
+func deleteUserBlobs(ctx context.Context, store storage.BlobStore, userID uint) (int, error) {
+	var keys []string
+	err := store.Walk(ctx, fmt.Sprintf("%s%d/", avatarKeyPrefix, userID), func(key string) error {
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for i, key := range keys {
+		if err := store.Delete(ctx, key); err != nil {
+			return i, err
+		}
+	}
+	return len(keys), nil
+}
+
+// blobOwner extracts the user id from an avatars/{id}/... key.
+func blobOwner(key string) (uint, bool) {
+	rest, ok := strings.CutPrefix(key, avatarKeyPrefix)
+	if !ok {
+		return 0, false
+	}
+	idStr, _, ok := strings.Cut(rest, "/")
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"user_service/internal/model"
+	"user_service/internal/storage"
+)
+
+// memStore is a BlobStore in memory.
+type memStore struct {
+	mu    sync.Mutex
+	blobs map[string][]byte
+}
+
+func newMemStore(keys ...string) *memStore {
+	s := &memStore{blobs: make(map[string][]byte)}
+	for _, key := range keys {
+		s.blobs[key] = []byte(key)
+	}
+	return s
+}
+
+func (s *memStore) Put(_ context.Context, key string, r io.Reader, _ string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blobs[key] = data
+	return nil
+}
+
+func (s *memStore) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.blobs[key]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *memStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.blobs, key)
+	return nil
+}
+
+// Walk visits the keys present when it starts in order, so fn may delete them.
+func (s *memStore) Walk(_ context.Context, prefix string, fn func(key string) error) error {
+	for _, key := range s.keys() {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if err := fn(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *memStore) URL(key string) string {
+	return "/files/" + key
+}
+
+func (s *memStore) SignedURL(_ context.Context, key string, _ time.Duration) (string, error) {
+	return "/files/" + key + "?signature=x", nil
+}
+
+func (s *memStore) keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.blobs))
+	for key := range s.blobs {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// fakeUsers implements the parts of UserRepository the tests use over a map of
+// active users.
+type fakeUsers struct {
+	UserRepository
+	users map[uint]*model.User
+	// lookups records the ids passed to each ActiveUserIDs call.
+	lookups [][]uint
+}
+
+func newFakeUsers(users ...model.User) *fakeUsers {
+	f := &fakeUsers{users: make(map[uint]*model.User)}
+	for _, u := range users {
+		f.users[u.ID] = &u
+	}
+	return f
+}
+
+func (f *fakeUsers) GetUserByID(id uint) (*model.User, error) {
+	u, ok := f.users[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *u
+	return &copied, nil
+}
+
+func (f *fakeUsers) UpdateUser(u *model.User) error {
+	copied := *u
+	f.users[u.ID] = &copied
+	return nil
+}
+
+func (f *fakeUsers) DeleteUser(id uint) error {
+	if _, ok := f.users[id]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	delete(f.users, id)
+	return nil
+}
+
+func (f *fakeUsers) GetUsersByIDs(ids []uint) ([]model.User, error) {
+	var users []model.User
+	for _, id := range ids {
+		if u, ok := f.users[id]; ok {
+			users = append(users, *u)
+		}
+	}
+	return users, nil
+}
+
+func (f *fakeUsers) ActiveUserIDs(ids []uint) ([]uint, error) {
+	f.lookups = append(f.lookups, slices.Clone(ids))
+	var active []uint
+	for _, id := range ids {
+		if _, ok := f.users[id]; ok {
+			active = append(active, id)
+		}
+	}
+	return active, nil
+}
+
+func testUser(id uint, username string) model.User {
+	u := model.User{Username: username}
+	u.ID = id
+	return u
+}
+
+func TestBlobOwner(t *testing.T) {
+	tests := []struct {
+		key string
+		id  uint
+		ok  bool
+	}{
+		{"avatars/7/256.jpg", 7, true},
+		{"avatars/123/default.png", 123, true},
+		{"avatars/7", 0, false},
+		{"avatars/0/256.jpg", 0, false},
+		{"avatars/-1/256.jpg", 0, false},
+		{"avatars/x/256.jpg", 0, false},
+		{"avatars/99999999999/256.jpg", 0, false},
+		{"other/7/256.jpg", 0, false},
+		{"avatars//256.jpg", 0, false},
+	}
+
+	for _, tt := range tests {
+		id, ok := blobOwner(tt.key)
+		if id != tt.id || ok != tt.ok {
+			t.Errorf("blobOwner(%q) = %d, %t, want %d, %t", tt.key, id, ok, tt.id, tt.ok)
+		}
+	}
+}
+
+func TestBlobCollectorCollect(t *testing.T) {
+	store := newMemStore(
+		"avatars/1/256.jpg", "avatars/1/default.png",
+		"avatars/2/256.jpg", "avatars/2/orig.jpg",
+		"avatars/3/orig.jpg",
+		"avatars/junk",
+		"other/2/file",
+	)
+	users := newFakeUsers(testUser(1, "alice"), testUser(3, "carol"))
+
+	deleted, err := NewBlobCollector(users, store).Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("deleted %d blobs, want 2", deleted)
+	}
+
+	want := []string{"avatars/1/256.jpg", "avatars/1/default.png", "avatars/3/orig.jpg", "avatars/junk", "other/2/file"}
+	if got := store.keys(); !slices.Equal(got, want) {
+		t.Errorf("kept %v, want %v", got, want)
+	}
+}
+
+func TestBlobCollectorCollectBatches(t *testing.T) {
+	store := newMemStore()
+	users := newFakeUsers()
+	const owners = 2*blobOwnerBatchSize + 10
+	for id := uint(1); id <= owners; id++ {
+		store.blobs[fmt.Sprintf("avatars/%d/64.jpg", id)] = nil
+		store.blobs[fmt.Sprintf("avatars/%d/256.jpg", id)] = nil
+		if id%2 == 0 {
+			users.users[id] = &model.User{}
+		}
+	}
+
+	deleted, err := NewBlobCollector(users, store).Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if deleted != owners {
+		t.Errorf("deleted %d blobs, want %d", deleted, owners)
+	}
+	if len(store.blobs) != owners {
+		t.Errorf("kept %d blobs, want %d", len(store.blobs), owners)
+	}
+
+	if len(users.lookups) != 3 {
+		t.Errorf("looked up owners in %d batches, want 3", len(users.lookups))
+	}
+	for _, ids := range users.lookups {
+		if len(ids) > blobOwnerBatchSize {
+			t.Errorf("batch of %d owners exceeds %d", len(ids), blobOwnerBatchSize)
+		}
+	}
+}
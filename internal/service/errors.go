@@ -0,0 +1,70 @@
+package service
+
+import "errors"
+
+// Error kinds. Every domain error wraps exactly one of them, so callers can
+// check the category with errors.Is(err, ErrNotFound) and friends.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrForbidden    = errors.New("forbidden")
+	ErrValidation   = errors.New("validation failed")
+	ErrBadRequest   = errors.New("bad request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrTooLarge     = errors.New("payload too large")
+	ErrUnsupported  = errors.New("unsupported media type")
+)
+
+// Error is a domain error carrying a machine-readable code for API clients.
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+func newError(kind error, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// NewBadRequestError reports a malformed request, such as an unparsable parameter.
+func NewBadRequestError(code, message string) error {
+	return newError(ErrBadRequest, code, message)
+}
+
+// NewUnauthorizedError reports a missing or unusable caller identity.
+func NewUnauthorizedError(code, message string) error {
+	return newError(ErrUnauthorized, code, message)
+}
+
+// NewForbiddenError reports a caller that is known but not allowed to make the request.
+func NewForbiddenError(code, message string) error {
+	return newError(ErrForbidden, code, message)
+}
+
+// FieldError describes why a single request field was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every rejected field of a request.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	return "validation failed"
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
@@ -0,0 +1,429 @@
+package service
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"time"
+	"user_service/internal/auth"
+	"user_service/internal/model"
+	"user_service/internal/repository"
+	"user_service/internal/transport/response"
+	"user_service/pkg/pagination"
+)
+
+var (
+	ErrUserNotFound     = newError(ErrNotFound, "user_not_found", "user not found")
+	ErrSelfFollow       = newError(ErrValidation, "self_follow", "users cannot follow themselves")
+	ErrAlreadyFollowing = newError(ErrConflict, "already_following", "already following this user")
+	ErrNotFollowing     = newError(ErrNotFound, "not_following", "not following this user")
+	ErrRequestPending   = newError(ErrConflict, "follow_request_pending", "follow request already pending")
+	ErrRequestNotFound  = newError(ErrNotFound, "follow_request_not_found", "follow request not found")
+	ErrSelfBlock        = newError(ErrValidation, "self_block", "users cannot block themselves")
+	ErrBlocked          = newError(ErrForbidden, "blocked", "following is not allowed between these users")
+	ErrAlreadyBlocked   = newError(ErrConflict, "already_blocked", "user is already blocked")
+	ErrNotBlocked       = newError(ErrNotFound, "not_blocked", "user is not blocked")
+	ErrPrivateProfile   = newError(ErrForbidden, "private_profile", "this profile is private")
+)
+
+type FollowService struct {
+	relations repository.FollowerRelationRepository
+	users     UserRepository
+	settings  repository.SettingsRepository
+}
+
+func NewFollowService(
+	relations repository.FollowerRelationRepository,
+	users UserRepository,
+	settings repository.SettingsRepository,
+) *FollowService {
+	return &FollowService{relations: relations, users: users, settings: settings}
+}
+
+// Follow makes followerID a follower of userID.
+// Following a private account creates a pending request the target has to approve.
+func (s *FollowService) Follow(followerID, userID uint) (*response.FollowRelationResponse, error) {
+	if followerID == userID {
+		return nil, ErrSelfFollow
+	}
+
+	if _, err := s.users.GetUserByID(userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	// A block in either direction forbids the follow.
+	if blocked, err := s.relations.IsBlocked(followerID, userID); err != nil {
+		return nil, err
+	} else if blocked {
+		return nil, ErrBlocked
+	}
+
+	if existing, err := s.relations.GetByPair(userID, followerID); err == nil {
+		switch existing.Status {
+		case model.StatusBlocked:
+			return nil, ErrBlocked
+		case model.StatusPending:
+			return nil, ErrRequestPending
+		default:
+			return nil, ErrAlreadyFollowing
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	private, err := isPrivate(s.settings, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	relation := &model.FollowerRelation{
+		UserID:     userID,
+		FollowerID: followerID,
+		Status:     model.StatusApproved,
+	}
+	if private {
+		pending := model.NewPrivateFollowerRelation(userID, followerID)
+		relation = &pending
+	}
+
+	err = s.relations.Transaction(func(tx repository.FollowerRelationRepository) error {
+		if err := tx.Create(relation); err != nil {
+			// A concurrent request may have created the pair between the check and the insert.
+			if errors.Is(err, repository.ErrDuplicate) {
+				return ErrAlreadyFollowing
+			}
+			return err
+		}
+
+		if relation.Status == model.StatusApproved {
+			return tx.AdjustFollowCounts(userID, followerID, 1)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toFollowRelationResponse(relation), nil
+}
+
+// Unfollow removes the relation between followerID and userID.
+// A pending request is withdrawn the same way.
+func (s *FollowService) Unfollow(followerID, userID uint) error {
+	return s.relations.Transaction(func(tx repository.FollowerRelationRepository) error {
+		relation, err := tx.GetByPair(userID, followerID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFollowing
+			}
+			return err
+		}
+		if relation.Status == model.StatusBlocked {
+			return ErrNotFollowing
+		}
+
+		return removeRelation(tx, relation)
+	})
+}
+
+// Block records that blockerID blocked blockedID and removes any follow between them.
+// The blocked relation reuses the (user_id, follower_id) pair with the blocker as user_id.
+func (s *FollowService) Block(blockerID, blockedID uint) (*response.FollowRelationResponse, error) {
+	if blockerID == blockedID {
+		return nil, ErrSelfBlock
+	}
+
+	if _, err := s.users.GetUserByID(blockedID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	var relation *model.FollowerRelation
+	err := s.relations.Transaction(func(tx repository.FollowerRelationRepository) error {
+		existing, err := tx.GetByPair(blockerID, blockedID)
+		switch {
+		case err == nil:
+			if existing.Status == model.StatusBlocked {
+				return ErrAlreadyBlocked
+			}
+			if err := tx.UpdateStatus(existing.ID, model.StatusBlocked); err != nil {
+				return err
+			}
+			// The blocked user was following the blocker.
+			if existing.Status == model.StatusApproved {
+				if err := tx.AdjustFollowCounts(blockerID, blockedID, -1); err != nil {
+					return err
+				}
+			}
+			existing.Status = model.StatusBlocked
+			relation = existing
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			relation = &model.FollowerRelation{
+				UserID:     blockerID,
+				FollowerID: blockedID,
+				Status:     model.StatusBlocked,
+			}
+			if err := tx.Create(relation); err != nil {
+				if errors.Is(err, repository.ErrDuplicate) {
+					return ErrAlreadyBlocked
+				}
+				return err
+			}
+		default:
+			return err
+		}
+
+		// The blocker stops following the blocked user as well.
+		reverse, err := tx.GetByPair(blockedID, blockerID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+		if reverse.Status == model.StatusBlocked {
+			return nil
+		}
+		return removeRelation(tx, reverse)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toFollowRelationResponse(relation), nil
+}
+
+// Unblock lifts a block previously placed by blockerID on blockedID.
+func (s *FollowService) Unblock(blockerID, blockedID uint) error {
+	relation, err := s.relations.GetByPair(blockerID, blockedID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotBlocked
+		}
+		return err
+	}
+	if relation.Status != model.StatusBlocked {
+		return ErrNotBlocked
+	}
+
+	return s.relations.Delete(relation.ID)
+}
+
+// ListFollowers returns a page of approved followers of userID as seen by viewer,
+// nil for anonymous callers.
+func (s *FollowService) ListFollowers(userID uint, viewer *auth.Principal, cursor string, limit int) (*response.FollowListResponse, error) {
+	return s.listConnections(userID, viewer, cursor, limit, s.relations.ListFollowers)
+}
+
+// ListFollowing returns a page of users userID follows as seen by viewer.
+func (s *FollowService) ListFollowing(userID uint, viewer *auth.Principal, cursor string, limit int) (*response.FollowListResponse, error) {
+	return s.listConnections(userID, viewer, cursor, limit, s.relations.ListFollowing)
+}
+
+func (s *FollowService) listConnections(
+	userID uint,
+	viewer *auth.Principal,
+	cursor string,
+	limit int,
+	list func(id uint, after *pagination.Cursor, limit int) ([]repository.FollowListEntry, error),
+) (*response.FollowListResponse, error) {
+	if err := s.checkGraphVisible(userID, viewer); err != nil {
+		return nil, err
+	}
+
+	after, err := pagination.DecodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch one extra row to know whether another page exists.
+	entries, err := list(userID, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &response.FollowListResponse{Users: make([]response.UserResponseShort, 0, len(entries))}
+	if len(entries) > limit {
+		entries = entries[:limit]
+		last := entries[len(entries)-1]
+		res.NextCursor = pagination.Cursor{
+			Key: last.RelationCreatedAt.Format(time.RFC3339Nano),
+			ID:  last.RelationID,
+		}.Encode()
+	}
+
+	for _, entry := range entries {
+		res.Users = append(res.Users, response.UserResponseShort{
+			ID:        entry.ID,
+			Username:  entry.Username,
+			AvatarURL: entry.AvatarURL,
+		})
+	}
+
+	return res, nil
+}
+
+// checkGraphVisible verifies that viewer may see who userID follows and is followed by.
+// Private profiles only expose their graph to themselves, approved followers and
+// services granted follows:read.
+func (s *FollowService) checkGraphVisible(userID uint, viewer *auth.Principal) error {
+	if _, err := s.users.GetUserByID(userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	viewerID := viewerUserID(viewer)
+	if viewerID == userID {
+		return nil
+	}
+
+	if viewerID != 0 {
+		blocked, err := s.relations.IsBlocked(userID, viewerID)
+		if err != nil {
+			return err
+		}
+		if blocked {
+			return ErrUserNotFound
+		}
+	}
+
+	private, err := isPrivate(s.settings, userID)
+	if err != nil || !private {
+		return err
+	}
+
+	allowed, err := canSeePrivate(s.relations, userID, viewer, auth.ScopeFollowsRead)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrPrivateProfile
+	}
+
+	return nil
+}
+
+// IncomingRequests lists pending follow requests addressed to userID.
+func (s *FollowService) IncomingRequests(userID uint) ([]response.FollowRelationResponse, error) {
+	relations, err := s.relations.ListPendingIncoming(userID)
+	if err != nil {
+		return nil, err
+	}
+	return toFollowRelationResponses(relations), nil
+}
+
+// OutgoingRequests lists pending follow requests sent by followerID.
+func (s *FollowService) OutgoingRequests(followerID uint) ([]response.FollowRelationResponse, error) {
+	relations, err := s.relations.ListPendingOutgoing(followerID)
+	if err != nil {
+		return nil, err
+	}
+	return toFollowRelationResponses(relations), nil
+}
+
+// ApproveRequest accepts a pending request addressed to userID.
+func (s *FollowService) ApproveRequest(userID, requestID uint) (*response.FollowRelationResponse, error) {
+	var relation *model.FollowerRelation
+	err := s.relations.Transaction(func(tx repository.FollowerRelationRepository) error {
+		var err error
+		relation, err = pendingRequest(tx, requestID)
+		if err != nil {
+			return err
+		}
+		if relation.UserID != userID {
+			return ErrRequestNotFound
+		}
+
+		if err := tx.UpdateStatus(relation.ID, model.StatusApproved); err != nil {
+			return err
+		}
+		relation.Status = model.StatusApproved
+
+		return tx.AdjustFollowCounts(relation.UserID, relation.FollowerID, 1)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toFollowRelationResponse(relation), nil
+}
+
+// RejectRequest declines a pending request addressed to userID.
+func (s *FollowService) RejectRequest(userID, requestID uint) error {
+	return s.relations.Transaction(func(tx repository.FollowerRelationRepository) error {
+		relation, err := pendingRequest(tx, requestID)
+		if err != nil {
+			return err
+		}
+		if relation.UserID != userID {
+			return ErrRequestNotFound
+		}
+
+		return tx.Delete(relation.ID)
+	})
+}
+
+// CancelRequest withdraws a pending request sent by followerID.
+func (s *FollowService) CancelRequest(followerID, requestID uint) error {
+	return s.relations.Transaction(func(tx repository.FollowerRelationRepository) error {
+		relation, err := pendingRequest(tx, requestID)
+		if err != nil {
+			return err
+		}
+		if relation.FollowerID != followerID {
+			return ErrRequestNotFound
+		}
+
+		return tx.Delete(relation.ID)
+	})
+}
+
+func pendingRequest(relations repository.FollowerRelationRepository, requestID uint) (*model.FollowerRelation, error) {
+	relation, err := relations.GetByID(requestID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRequestNotFound
+		}
+		return nil, err
+	}
+	if relation.Status != model.StatusPending {
+		return nil, ErrRequestNotFound
+	}
+	return relation, nil
+}
+
+// removeRelation deletes a follow or follow request and keeps the counters in sync.
+// It must run inside a transaction that holds the lock on relation.
+func removeRelation(tx repository.FollowerRelationRepository, relation *model.FollowerRelation) error {
+	if err := tx.Delete(relation.ID); err != nil {
+		return err
+	}
+	if relation.Status == model.StatusApproved {
+		return tx.AdjustFollowCounts(relation.UserID, relation.FollowerID, -1)
+	}
+	return nil
+}
+
+func toFollowRelationResponses(relations []model.FollowerRelation) []response.FollowRelationResponse {
+	res := make([]response.FollowRelationResponse, 0, len(relations))
+	for i := range relations {
+		res = append(res, *toFollowRelationResponse(&relations[i]))
+	}
+	return res
+}
+
+func toFollowRelationResponse(relation *model.FollowerRelation) *response.FollowRelationResponse {
+	return &response.FollowRelationResponse{
+		ID:         relation.ID,
+		UserID:     relation.UserID,
+		FollowerID: relation.FollowerID,
+		Status:     relation.Status,
+		CreatedAt:  relation.CreatedAt,
+	}
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"time"
+	"user_service/internal/repository"
+	"user_service/pkg/logging"
+)
+
+// CounterReconciler recomputes users.followers_count and users.following_count
+// from follower_relations and fixes rows that drifted.
+type CounterReconciler struct {
+	counters  repository.FollowCounterRepository
+	batchSize int
+}
+
+func NewCounterReconciler(counters repository.FollowCounterRepository, batchSize int) *CounterReconciler {
+	return &CounterReconciler{counters: counters, batchSize: batchSize}
+}
+
+// Reconcile walks all users in batches and returns how many rows were corrected.
+func (r *CounterReconciler) Reconcile(ctx context.Context) (int64, error) {
+	var total int64
+	var afterID uint
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+
+		lastID, fixed, err := r.counters.ReconcileBatch(afterID, r.batchSize)
+		if err != nil {
+			return total, err
+		}
+		total += fixed
+
+		if lastID == 0 {
+			return total, nil
+		}
+		afterID = lastID
+	}
+}
+
+// Run reconciles every interval until ctx is cancelled.
+func (r *CounterReconciler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fixed, err := r.Reconcile(ctx)
+			if err != nil {
+				logging.Instance.Error("Follower counter reconciliation failed: ", err)
+				continue
+			}
+			logging.Instance.Infof("Follower counter reconciliation corrected %d users", fixed)
+		}
+	}
+}
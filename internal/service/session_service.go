@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"user_service/internal/auth"
+)
+
+var ErrSessionNotRevocable = newError(ErrBadRequest, "session_not_revocable", "token has no jti and can only be revoked together with all sessions")
+
+// SessionService revokes issued tokens before they expire.
+type SessionService struct {
+	users       UserRepository
+	revocations *auth.RevocationList
+}
+
+func NewSessionService(users UserRepository, revocations *auth.RevocationList) *SessionService {
+	return &SessionService{users: users, revocations: revocations}
+}
+
+// RevokeCurrentSession revokes the token the caller authenticated with.
+func (s *SessionService) RevokeCurrentSession(ctx context.Context, principal *auth.Principal) error {
+	if principal.TokenID == "" {
+		return ErrSessionNotRevocable
+	}
+	return s.revocations.RevokeToken(ctx, principal)
+}
+
+// RevokeAllSessions revokes every token issued to userID so far, including the
+// one used for this request.
+func (s *SessionService) RevokeAllSessions(ctx context.Context, userID uint) error {
+	return s.revocations.RevokeUser(ctx, userID)
+}
+
+// ForceLogout revokes every session of another user on behalf of a moderator.
+func (s *SessionService) ForceLogout(ctx context.Context, userID uint) error {
+	if _, err := s.users.GetUserByID(userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	return s.revocations.RevokeUser(ctx, userID)
+}
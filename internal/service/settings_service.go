@@ -0,0 +1,84 @@
+package service
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"user_service/internal/model"
+	"user_service/internal/repository"
+	"user_service/internal/transport/request"
+	"user_service/internal/transport/response"
+)
+
+var ErrEmptySettingsUpdate = newError(ErrValidation, "empty_settings_update", "at least one setting must be provided")
+
+type SettingsService struct {
+	repo repository.SettingsRepository
+}
+
+func NewSettingsService(repo repository.SettingsRepository) *SettingsService {
+	return &SettingsService{repo: repo}
+}
+
+func (s *SettingsService) GetSettings(userID uint) (*response.SettingsResponse, error) {
+	settings, err := s.getOrCreate(userID)
+	if err != nil {
+		return nil, err
+	}
+	return toSettingsResponse(settings), nil
+}
+
+func (s *SettingsService) UpdateSettings(userID uint, req request.UpdateSettingsRequest) (*response.SettingsResponse, error) {
+	if req.IsPrivate == nil && req.DarkMode == nil {
+		return nil, ErrEmptySettingsUpdate
+	}
+
+	settings, err := s.getOrCreate(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.IsPrivate != nil {
+		settings.IsPrivate = *req.IsPrivate
+	}
+	if req.DarkMode != nil {
+		settings.DarkMode = *req.DarkMode
+	}
+
+	if err := s.repo.Update(settings); err != nil {
+		return nil, err
+	}
+
+	return toSettingsResponse(settings), nil
+}
+
+// getOrCreate loads the settings of userID, creating the defaults for users
+// registered before settings rows were created on sign up.
+func (s *SettingsService) getOrCreate(userID uint) (*model.Settings, error) {
+	settings, err := s.repo.GetByUserID(userID)
+	if err == nil {
+		return settings, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	defaults := model.DefaultSettings()
+	defaults.UserID = userID
+	if err := s.repo.Create(&defaults); err != nil {
+		// Another request created the row first.
+		if errors.Is(err, repository.ErrDuplicate) {
+			return s.repo.GetByUserID(userID)
+		}
+		return nil, err
+	}
+
+	return &defaults, nil
+}
+
+func toSettingsResponse(settings *model.Settings) *response.SettingsResponse {
+	return &response.SettingsResponse{
+		IsPrivate: settings.IsPrivate,
+		DarkMode:  settings.DarkMode,
+		UpdatedAt: settings.UpdatedAt,
+	}
+}
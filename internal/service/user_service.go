@@ -1,51 +1,134 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"gorm.io/gorm"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"user_service/internal/auth"
 	"user_service/internal/model"
+	"user_service/internal/repository"
+	"user_service/internal/storage"
 	"user_service/internal/transport/request"
 	"user_service/internal/transport/response"
+	"user_service/pkg/logging"
+	"user_service/pkg/pagination"
 )
 
+var (
+	ErrUsernameTaken    = newError(ErrConflict, "username_taken", "username already taken")
+	ErrNotAccountOwner  = newError(ErrForbidden, "not_account_owner", "users can only modify their own account")
+	ErrUsernameReserved = newError(ErrConflict, "username_reserved", "username was recently released and is reserved for its previous owner")
+	ErrUsernameCooldown = newError(ErrConflict, "username_change_cooldown", "username was changed too recently")
+	ErrInvalidSearch    = newError(ErrValidation, "invalid_search_query", "search query must be between 1 and 64 characters long")
+)
+
+const maxSearchQueryLength = 64
+
+// UsernameMovedError is returned by ResolveUsername for a handle that was recently
+// released by a user who renamed; Username is their current handle.
+type UsernameMovedError struct {
+	UserID   uint
+	Username string
+}
+
+func (e *UsernameMovedError) Error() string {
+	return "username has moved to " + e.Username
+}
+
+// UsernamePolicy controls how often users may rename and how long released handles stay reserved.
+type UsernamePolicy struct {
+	ChangeCooldown    time.Duration
+	ReservationPeriod time.Duration
+}
+
+// PaginationPolicy bounds page sizes of the user listing and decides when its
+// total may be estimated instead of counted.
+type PaginationPolicy struct {
+	DefaultPageSize        int
+	MaxPageSize            int
+	CountEstimateThreshold int64
+}
+
 type UserRepository interface {
 	CreateUser(user *model.User) error
 	GetUserByID(id uint) (*model.User, error)
 	GetUserByUsername(username string) (*model.User, error)
+	// GetUsersByIDs returns the active users among ids, in no particular order.
+	GetUsersByIDs(ids []uint) ([]model.User, error)
 	UpdateUser(user *model.User) error
+	RenameUser(user *model.User, entry *model.UsernameHistory) error
 	DeleteUser(id uint) error
-	GetUsersPaginated(page, pageSize int) ([]model.User, error)
+	GetUsersPaginated(q repository.UserListQuery) ([]model.User, error)
+	CountUsers(q repository.UserListQuery) (int64, error)
+	ActiveUserIDs(ids []uint) ([]uint, error)
+	EstimateUserCount() (int64, error)
+	SearchUsers(query string, viewerID uint, after *pagination.Cursor, limit int) ([]model.User, error)
 }
 
 type UserService struct {
-	repo UserRepository
+	repo      UserRepository
+	relations repository.FollowerRelationRepository
+	settings  repository.SettingsRepository
+	history   repository.UsernameHistoryRepository
+	store     storage.BlobStore
+	sessions  *auth.RevocationList
+	policy    UsernamePolicy
+	paging    PaginationPolicy
 }
 
-func NewUserService(repo UserRepository) *UserService {
-	return &UserService{repo: repo}
+func NewUserService(
+	repo UserRepository,
+	relations repository.FollowerRelationRepository,
+	settings repository.SettingsRepository,
+	history repository.UsernameHistoryRepository,
+	store storage.BlobStore,
+	sessions *auth.RevocationList,
+	policy UsernamePolicy,
+	paging PaginationPolicy,
+) *UserService {
+	return &UserService{
+		repo:      repo,
+		relations: relations,
+		settings:  settings,
+		history:   history,
+		store:     store,
+		sessions:  sessions,
+		policy:    policy,
+		paging:    paging,
+	}
 }
 
-func (s *UserService) CreateUser(req request.CreateUserRequest) (*response.UserResponseFull, error) {
-
-	if req.Username == "" {
-		return nil, errors.New("username cannot be empty")
-	}
-	if len(req.Username) < 3 {
-		return nil, errors.New("username must be at least 3 characters long")
-	}
+// CreateUser registers a new user. The request is expected to have passed its binding rules.
+func (s *UserService) CreateUser(ctx context.Context, req request.CreateUserRequest) (*response.UserResponseFull, error) {
+	req.Username = normalizeUsername(req.Username)
 
-	if _, err := s.repo.GetUserByUsername(req.Username); err == nil {
-		return nil, errors.New("username already exists")
+	if _, err := s.checkUsernameAvailable(req.Username, 0); err != nil {
+		return nil, err
 	}
 
 	user := &model.User{
-		Username: req.Username,
-		Bio:      req.Bio,
+		Username:  req.Username,
+		Bio:       req.Bio,
+		AvatarURL: req.AvatarURL,
+		Settings:  model.DefaultSettings(),
 	}
 
 	if err := s.repo.CreateUser(user); err != nil {
+		// The unique index settles races between the check above and the insert.
+		if errors.Is(err, repository.ErrDuplicate) {
+			return nil, ErrUsernameTaken
+		}
 		return nil, err
 	}
 
+	if user.AvatarURL == "" {
+		s.generateDefaultAvatar(ctx, user)
+	}
+
 	return &response.UserResponseFull{
 		ID:        user.ID,
 		Username:  user.Username,
@@ -54,53 +137,412 @@ func (s *UserService) CreateUser(req request.CreateUserRequest) (*response.UserR
 	}, nil
 }
 
-func (s *UserService) GetUserByID(id uint) (*response.UserResponseFull, error) {
+// GetUserByID returns the profile of id as seen by viewer (nil for anonymous callers).
+// Users who blocked the viewer are reported as not found.
+func (s *UserService) GetUserByID(id uint, viewer *auth.Principal) (*response.UserResponseFull, error) {
 	user, err := s.repo.GetUserByID(id)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
 		return nil, err
 	}
 
-	return &response.UserResponseFull{
-		ID:             user.ID,
-		Username:       user.Username,
-		AvatarURL:      user.AvatarURL,
-		Bio:            user.Bio,
-		FollowersCount: user.FollowersCount,
-		FollowingCount: user.FollowingCount,
+	return s.profileFor(user, viewer)
+}
+
+// GetUserByUsername returns the profile addressed by a handle as seen by viewer.
+// A recently released handle yields a *UsernameMovedError.
+func (s *UserService) GetUserByUsername(username string, viewer *auth.Principal) (*response.UserResponseFull, error) {
+	user, err := s.ResolveUsername(username)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.profileFor(user, viewer)
+}
+
+// GetUsersByIDs returns the profiles of ids as seen by viewer, in the order
+// asked. Unknown ids and users who blocked the viewer are left out.
+func (s *UserService) GetUsersByIDs(ids []uint, viewer *auth.Principal) ([]response.UserResponseFull, error) {
+	users, err := s.repo.GetUsersByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint]*model.User, len(users))
+	for i := range users {
+		byID[users[i].ID] = &users[i]
+	}
+
+	profiles := make([]response.UserResponseFull, 0, len(users))
+	for _, id := range ids {
+		user, ok := byID[id]
+		if !ok {
+			continue
+		}
+
+		profile, err := s.profileFor(user, viewer)
+		if errors.Is(err, ErrUserNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, *profile)
+	}
+	return profiles, nil
+}
+
+// profileFor builds the public profile of user for viewer. Users who blocked the
+// viewer are reported as not found, and private profiles are trimmed for viewers
+// who are neither approved followers nor services granted users:read.
+func (s *UserService) profileFor(user *model.User, viewer *auth.Principal) (*response.UserResponseFull, error) {
+	if viewerID := viewerUserID(viewer); viewerID != 0 && viewerID != user.ID {
+		blocked, err := s.relations.IsBlocked(user.ID, viewerID)
+		if err != nil {
+			return nil, err
+		}
+		if blocked {
+			return nil, ErrUserNotFound
+		}
+	}
+
+	res := toUserResponseFull(user)
+
+	private, err := isPrivate(s.settings, user.ID)
+	if err != nil || !private {
+		return res, err
+	}
+
+	allowed, err := canSeePrivate(s.relations, user.ID, viewer, auth.ScopeUsersRead)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		res.Bio = ""
+		res.Restricted = true
+	}
+
+	return res, nil
+}
+
+// GetMe returns the caller's own profile with settings and pending request counts.
+func (s *UserService) GetMe(userID uint) (*response.UserResponseMe, error) {
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	settings, err := s.settings.GetByUserID(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		defaults := model.DefaultSettings()
+		settings, err = &defaults, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	incoming, err := s.relations.CountPendingIncoming(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	outgoing, err := s.relations.CountPendingOutgoing(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.UserResponseMe{
+		UserResponseFull:      *toUserResponseFull(user),
+		Settings:              *toSettingsResponse(settings),
+		PendingRequestsCount:  incoming,
+		OutgoingRequestsCount: outgoing,
 	}, nil
 }
 
-func (s *UserService) UpdateUser(userID uint, req request.UpdateUserRequest, requestUserID uint) (*response.UserResponseFull, error) {
+// UpdateUser changes the profile of userID on behalf of actor, who must own the
+// account or be an admin or moderator.
+func (s *UserService) UpdateUser(ctx context.Context, actor *auth.Principal, userID uint, req request.UpdateUserRequest) (*response.UserResponseFull, error) {
 
-	if userID != requestUserID {
-		return nil, errors.New("unauthorized: users can only update their own data")
+	if !canManageAccount(actor, userID, auth.RoleAdmin, auth.RoleModerator) {
+		return nil, ErrNotAccountOwner
 	}
 
 	user, err := s.repo.GetUserByID(userID)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
 		return nil, err
 	}
 
+	var rename *model.UsernameHistory
 	if req.Username != "" {
+		username := normalizeUsername(req.Username)
 
-		if len(req.Username) < 3 {
-			return nil, errors.New("username must be at least 3 characters long")
-		}
+		// Changing only the letter case of the current handle is not a rename.
+		if !strings.EqualFold(username, user.Username) {
+			reclaim, err := s.checkUsernameAvailable(username, user.ID)
+			if err != nil {
+				return nil, err
+			}
+			// Moderators renaming an offensive handle are not held up by the owner's
+			// cooldown, and neither are owners taking back their reserved old handle,
+			// which the cooldown would otherwise block for the whole reservation.
+			if actor.UserID == user.ID && !reclaim {
+				if err := s.checkRenameCooldown(user.ID); err != nil {
+					return nil, err
+				}
+			}
 
-		if existingUser, err := s.repo.GetUserByID(userID); err == nil && existingUser.ID != user.ID {
-			return nil, errors.New("username already taken")
+			now := time.Now()
+			rename = &model.UsernameHistory{
+				UserID:        user.ID,
+				OldUsername:   user.Username,
+				NewUsername:   username,
+				ChangedAt:     now,
+				ReservedUntil: now.Add(s.policy.ReservationPeriod),
+			}
 		}
-		user.Username = req.Username
+		user.Username = username
 	}
 
 	if req.Bio != "" {
 		user.Bio = req.Bio
 	}
 
-	if err := s.repo.UpdateUser(user); err != nil {
+	// The default avatar is derived from the username and follows renames.
+	regenerateAvatar := rename != nil && req.AvatarURL == "" && isDefaultAvatar(s.store, user)
+	if req.AvatarURL != "" {
+		user.AvatarURL = req.AvatarURL
+	}
+
+	if rename != nil {
+		err = s.repo.RenameUser(user, rename)
+	} else {
+		err = s.repo.UpdateUser(user)
+	}
+	if err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return nil, ErrUsernameTaken
+		}
 		return nil, err
 	}
 
+	if regenerateAvatar {
+		s.generateDefaultAvatar(ctx, user)
+	}
+
+	return toUserResponseFull(user), nil
+}
+
+// generateDefaultAvatar stores the default avatar of a saved user and records its
+// URL. Failures are only logged: users without one are served their identicon
+// on the fly, and it can be regenerated later.
+func (s *UserService) generateDefaultAvatar(ctx context.Context, user *model.User) {
+	previous := user.AvatarURL
+	err := storeDefaultAvatar(ctx, s.store, user)
+	if err == nil {
+		err = s.repo.UpdateUser(user)
+	}
+	if err != nil {
+		user.AvatarURL = previous
+		logging.Instance.WithField("user_id", user.ID).Error("Generating default avatar failed: ", err)
+	}
+}
+
+// canManageAccount reports whether actor may act on the account of userID: its
+// owner always can, other callers only with one of privileged roles.
+func canManageAccount(actor *auth.Principal, userID uint, privileged ...string) bool {
+	if actor == nil {
+		return false
+	}
+	return actor.UserID == userID || actor.HasAnyRole(privileged...)
+}
+
+// ResolveUsername finds the active user owning username. A handle that was
+// released recently and is still reserved yields a *UsernameMovedError pointing
+// to the account that gave it up.
+func (s *UserService) ResolveUsername(username string) (*model.User, error) {
+	username = normalizeUsername(username)
+
+	user, err := s.repo.GetUserByUsername(username)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	entry, err := s.history.ActiveReservation(username, time.Now())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	owner, err := s.repo.GetUserByID(entry.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return nil, &UsernameMovedError{UserID: owner.ID, Username: owner.Username}
+}
+
+// DeleteUser deletes the account and then its stored media on behalf of actor,
+// who must own the account or be an admin. Media left behind by a failed cleanup
+// is picked up by the BlobCollector.
+func (s *UserService) DeleteUser(ctx context.Context, actor *auth.Principal, userID uint) error {
+
+	if !canManageAccount(actor, userID, auth.RoleAdmin) {
+		return ErrNotAccountOwner
+	}
+
+	if _, err := s.repo.GetUserByID(userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	// Tokens are revoked first: a failure leaves the account intact rather
+	// than deleted with sessions still usable.
+	if err := s.sessions.RevokeUser(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteUser(userID); err != nil {
+		return err
+	}
+
+	if _, err := deleteUserBlobs(ctx, s.store, userID); err != nil {
+		logging.Instance.WithField("user_id", userID).Error("Deleting media of deleted user failed: ", err)
+	}
+	return nil
+}
+
+// GetUsersPaginated lists users page by page, or by keyset cursor when the request
+// carries one. Page sizes above the configured maximum are clamped rather than rejected.
+func (s *UserService) GetUsersPaginated(req request.ListUsersRequest, viewerID uint) (*response.PaginatedUsersResponse, error) {
+	q := repository.UserListQuery{
+		Page:           req.Page,
+		PageSize:       req.PageSize,
+		Sort:           req.Sort,
+		Desc:           req.Order == "desc",
+		UsernamePrefix: normalizeUsername(req.Username),
+		CreatedAfter:   req.CreatedAfter,
+		CreatedBefore:  req.CreatedBefore,
+		MinFollowers:   req.MinFollowers,
+		ViewerID:       viewerID,
+	}
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.PageSize < 1 {
+		q.PageSize = s.paging.DefaultPageSize
+	}
+	if q.PageSize > s.paging.MaxPageSize {
+		q.PageSize = s.paging.MaxPageSize
+	}
+	if q.Sort == "" {
+		q.Sort = repository.UserSortCreatedAt
+	}
+
+	sortSpec := q.Sort
+	if q.Desc {
+		sortSpec = "-" + sortSpec
+	}
+
+	if req.Cursor != nil {
+		q.Page = 0
+		after, err := pagination.DecodeCursor(*req.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		// A cursor only makes sense for the ordering it was issued for.
+		if after != nil && after.Sort != sortSpec {
+			return nil, pagination.ErrInvalidCursor
+		}
+		q.After = after
+	}
+
+	users, err := s.repo.GetUsersPaginated(q)
+	if err != nil {
+		return nil, err
+	}
+
+	hasNext := len(users) > q.PageSize
+	if hasNext {
+		users = users[:q.PageSize]
+	}
+
+	total, estimated, err := s.countUsers(q)
+	if err != nil {
+		return nil, err
+	}
+
+	userResponses := make([]response.UserResponseShort, 0, len(users))
+	for _, user := range users {
+		userResponses = append(userResponses, response.UserResponseShort{
+			ID:        user.ID,
+			Username:  user.Username,
+			AvatarURL: user.AvatarURL,
+		})
+	}
+
+	res := &response.PaginatedUsersResponse{
+		Users:          userResponses,
+		Total:          total,
+		TotalEstimated: estimated,
+		Page:           q.Page,
+		Size:           q.PageSize,
+		HasNext:        hasNext,
+	}
+	if req.Cursor != nil && hasNext {
+		last := users[len(users)-1]
+		res.NextCursor = pagination.Cursor{Key: cursorSortKey(&last, q.Sort), ID: last.ID, Sort: sortSpec}.Encode()
+	}
+	return res, nil
+}
+
+// cursorSortKey renders the value user is ordered by under sort as a cursor key.
+func cursorSortKey(user *model.User, sort string) string {
+	switch sort {
+	case repository.UserSortUsername:
+		return user.Username
+	case repository.UserSortFollowersCount:
+		return strconv.FormatUint(uint64(user.FollowersCount), 10)
+	default:
+		return user.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+}
+
+// countUsers returns the listing total. Unfiltered listings of large tables use
+// the planner estimate since an exact COUNT(*) scans the whole table.
+func (s *UserService) countUsers(q repository.UserListQuery) (int64, bool, error) {
+	if s.paging.CountEstimateThreshold > 0 && !q.HasFilters() {
+		estimate, err := s.repo.EstimateUserCount()
+		if err != nil {
+			return 0, false, err
+		}
+		if estimate >= s.paging.CountEstimateThreshold {
+			return estimate, true, nil
+		}
+	}
+
+	total, err := s.repo.CountUsers(q)
+	return total, false, err
+}
+
+func toUserResponseFull(user *model.User) *response.UserResponseFull {
 	return &response.UserResponseFull{
 		ID:             user.ID,
 		Username:       user.Username,
@@ -108,35 +550,87 @@ func (s *UserService) UpdateUser(userID uint, req request.UpdateUserRequest, req
 		Bio:            user.Bio,
 		FollowersCount: user.FollowersCount,
 		FollowingCount: user.FollowingCount,
-	}, nil
+	}
 }
 
-func (s *UserService) DeleteUser(userID uint, requestUserID uint) error {
-
-	if userID != requestUserID {
-		return errors.New("unauthorized: users can only delete their own account")
+// SearchUsers returns a page of users matching q by username prefix or bio text.
+// The exact handle match comes first, followed by the most followed users.
+func (s *UserService) SearchUsers(q string, viewerID uint, cursor string, limit int) (*response.SearchUsersResponse, error) {
+	q = strings.TrimSpace(q)
+	if q == "" || utf8.RuneCountInString(q) > maxSearchQueryLength {
+		return nil, ErrInvalidSearch
 	}
 
-	return s.repo.DeleteUser(userID)
-}
+	after, err := pagination.DecodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
 
-func (s *UserService) GetUsersPaginated(page, pageSize int) (*response.PaginatedUsersResponse, error) {
-	users, err := s.repo.GetUsersPaginated(page, pageSize)
+	// Fetch one extra row to know whether another page exists.
+	users, err := s.repo.SearchUsers(q, viewerID, after, limit+1)
 	if err != nil {
 		return nil, err
 	}
 
-	var userResponses []response.UserResponseShort
+	res := &response.SearchUsersResponse{Users: make([]response.UserResponseShort, 0, len(users))}
+	if len(users) > limit {
+		users = users[:limit]
+		last := users[len(users)-1]
+
+		next := pagination.Cursor{Key: strconv.FormatUint(uint64(last.FollowersCount), 10), ID: last.ID}
+		if strings.EqualFold(last.Username, q) {
+			next.Key = repository.SearchExactKey
+		}
+		res.NextCursor = next.Encode()
+	}
+
 	for _, user := range users {
-		userResponses = append(userResponses, response.UserResponseShort{
+		res.Users = append(res.Users, response.UserResponseShort{
 			ID:        user.ID,
 			Username:  user.Username,
 			AvatarURL: user.AvatarURL,
 		})
 	}
 
-	return &response.PaginatedUsersResponse{
-		Users: userResponses,
-		Total: len(userResponses),
-	}, nil
+	return res, nil
+}
+
+// checkUsernameAvailable reports whether userID may take username. It returns true
+// when the handle is userID's own active reservation, which they are reclaiming.
+func (s *UserService) checkUsernameAvailable(username string, userID uint) (bool, error) {
+	if existingUser, err := s.repo.GetUserByUsername(username); err == nil && existingUser.ID != userID {
+		return false, ErrUsernameTaken
+	} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+
+	reservation, err := s.history.ActiveReservation(username, time.Now())
+	if err == nil && reservation.UserID != userID {
+		return false, ErrUsernameReserved
+	} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+
+	return err == nil, nil
+}
+
+// checkRenameCooldown rejects a rename while the previous one is within the cooldown.
+func (s *UserService) checkRenameCooldown(userID uint) error {
+	last, err := s.history.LastChange(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if time.Since(last.ChangedAt) < s.policy.ChangeCooldown {
+		return ErrUsernameCooldown
+	}
+	return nil
+}
+
+// normalizeUsername brings a handle to its stored form: trimmed and lower case.
+func normalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
 }
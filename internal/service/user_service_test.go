@@ -0,0 +1,331 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"user_service/internal/auth"
+	"user_service/internal/model"
+	"user_service/internal/repository"
+	"user_service/internal/transport/request"
+	"user_service/pkg/pagination"
+)
+
+// memCutoffs is an auth.RevocationStore recording per-user cutoffs.
+type memCutoffs struct {
+	cutoffs map[uint]time.Time
+}
+
+func (m *memCutoffs) RevokeToken(string, uint, time.Time) error {
+	return nil
+}
+
+func (m *memCutoffs) IsTokenRevoked(string) (bool, error) {
+	return false, nil
+}
+
+func (m *memCutoffs) DeleteExpiredTokens(time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (m *memCutoffs) RevokeUserTokens(userID uint, before time.Time) error {
+	m.cutoffs[userID] = before
+	return nil
+}
+
+func (m *memCutoffs) UserTokensRevokedBefore(userID uint) (time.Time, error) {
+	return m.cutoffs[userID], nil
+}
+
+func TestDeleteUser(t *testing.T) {
+	users := newFakeUsers(testUser(7, "bob"))
+	store := newMemStore("avatars/7/256.jpg", "avatars/7/default.png", "avatars/8/256.jpg")
+	revocations := &memCutoffs{cutoffs: make(map[uint]time.Time)}
+	s := NewUserService(users, nil, nil, nil, store, auth.NewRevocationList(revocations, time.Minute), UsernamePolicy{}, PaginationPolicy{})
+	admin := &auth.Principal{UserID: 1, Roles: []string{auth.RoleAdmin}}
+
+	if err := s.DeleteUser(context.Background(), &auth.Principal{UserID: 8}, 7); !errors.Is(err, ErrNotAccountOwner) {
+		t.Errorf("deleting another user's account: got %v, want ErrNotAccountOwner", err)
+	}
+
+	if err := s.DeleteUser(context.Background(), admin, 9); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("deleting an unknown user: got %v, want ErrUserNotFound", err)
+	}
+	if _, ok := revocations.cutoffs[9]; ok {
+		t.Error("tokens of an unknown user were revoked")
+	}
+
+	if err := s.DeleteUser(context.Background(), &auth.Principal{UserID: 7}, 7); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if _, ok := users.users[7]; ok {
+		t.Error("user was not deleted")
+	}
+	if _, ok := revocations.cutoffs[7]; !ok {
+		t.Error("tokens of the deleted user were not revoked")
+	}
+	if got := store.keys(); len(got) != 1 || got[0] != "avatars/8/256.jpg" {
+		t.Errorf("blobs left %v, want only those of user 8", got)
+	}
+}
+
+// fakeSettings holds the settings of users that have any.
+type fakeSettings struct {
+	repository.SettingsRepository
+	private map[uint]bool
+}
+
+func (f *fakeSettings) GetByUserID(userID uint) (*model.Settings, error) {
+	private, ok := f.private[userID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &model.Settings{UserID: userID, IsPrivate: private}, nil
+}
+
+// fakeRelations holds follower relations keyed by followed and follower id.
+type fakeRelations struct {
+	repository.FollowerRelationRepository
+	relations map[[2]uint]string
+}
+
+func (f *fakeRelations) GetByPair(userID, followerID uint) (*model.FollowerRelation, error) {
+	status, ok := f.relations[[2]uint{userID, followerID}]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &model.FollowerRelation{UserID: userID, FollowerID: followerID, Status: status}, nil
+}
+
+func (f *fakeRelations) IsBlocked(blockerID, blockedID uint) (bool, error) {
+	return f.relations[[2]uint{blockerID, blockedID}] == model.StatusBlocked, nil
+}
+
+func (f *fakeRelations) ListFollowers(uint, *pagination.Cursor, int) ([]repository.FollowListEntry, error) {
+	return nil, nil
+}
+
+func TestGetUsersByIDs(t *testing.T) {
+	public, private, blocker := testUser(1, "public"), testUser(2, "private"), testUser(3, "blocker")
+	public.Bio, private.Bio, blocker.Bio = "public bio", "private bio", "blocker bio"
+	users := newFakeUsers(public, private, blocker)
+	settings := &fakeSettings{private: map[uint]bool{1: false, 2: true}}
+	relations := &fakeRelations{relations: map[[2]uint]string{
+		{2, 10}: model.StatusApproved,
+		{3, 11}: model.StatusBlocked,
+	}}
+	s := NewUserService(users, relations, settings, nil, newMemStore(), nil, UsernamePolicy{}, PaginationPolicy{})
+
+	tests := []struct {
+		name   string
+		viewer *auth.Principal
+		// want lists the returned users, an asterisk marking a trimmed one.
+		want []string
+	}{
+		{"anonymous", nil, []string{"blocker", "private*", "public"}},
+		{"approved follower", &auth.Principal{UserID: 10}, []string{"blocker", "private", "public"}},
+		{"blocked user", &auth.Principal{UserID: 11}, []string{"private*", "public"}},
+		{"service with users:read", &auth.Principal{APIKeyID: 1, Scopes: []string{auth.ScopeUsersRead}}, []string{"blocker", "private", "public"}},
+		{"service without users:read", &auth.Principal{APIKeyID: 1, Scopes: []string{auth.ScopeFollowsRead}}, []string{"blocker", "private*", "public"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profiles, err := s.GetUsersByIDs([]uint{3, 99, 2, 1}, tt.viewer)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, p := range profiles {
+				name := p.Username
+				if p.Restricted {
+					if p.Bio != "" {
+						t.Errorf("%s is restricted but shows its bio", p.Username)
+					}
+					name += "*"
+				}
+				got = append(got, name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListFollowersOfPrivateProfile(t *testing.T) {
+	users := newFakeUsers(testUser(2, "private"))
+	settings := &fakeSettings{private: map[uint]bool{2: true}}
+	relations := &fakeRelations{relations: map[[2]uint]string{{2, 10}: model.StatusApproved}}
+	s := NewFollowService(relations, users, settings)
+
+	tests := []struct {
+		name   string
+		viewer *auth.Principal
+		want   error
+	}{
+		{"anonymous", nil, ErrPrivateProfile},
+		{"owner", &auth.Principal{UserID: 2}, nil},
+		{"approved follower", &auth.Principal{UserID: 10}, nil},
+		{"other user", &auth.Principal{UserID: 11}, ErrPrivateProfile},
+		{"service with follows:read", &auth.Principal{APIKeyID: 1, Scopes: []string{auth.ScopeFollowsRead}}, nil},
+		{"service with users:read", &auth.Principal{APIKeyID: 1, Scopes: []string{auth.ScopeUsersRead}}, ErrPrivateProfile},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.ListFollowers(2, tt.viewer, "", 10); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// GetUsersPaginated orders the users by q.Sort and id the way the repository
+// does, returning one row past the page.
+func (f *fakeUsers) GetUsersPaginated(q repository.UserListQuery) ([]model.User, error) {
+	compare := func(a, b *model.User) int {
+		var c int
+		switch q.Sort {
+		case repository.UserSortUsername:
+			c = strings.Compare(a.Username, b.Username)
+		case repository.UserSortFollowersCount:
+			c = cmp.Compare(a.FollowersCount, b.FollowersCount)
+		default:
+			c = a.CreatedAt.Compare(b.CreatedAt)
+		}
+		if c == 0 {
+			c = cmp.Compare(a.ID, b.ID)
+		}
+		if q.Desc {
+			c = -c
+		}
+		return c
+	}
+
+	users := make([]model.User, 0, len(f.users))
+	for _, u := range f.users {
+		users = append(users, *u)
+	}
+	slices.SortFunc(users, func(a, b model.User) int { return compare(&a, &b) })
+
+	if q.After != nil {
+		after := testUser(q.After.ID, q.After.Key)
+		switch q.Sort {
+		case repository.UserSortFollowersCount:
+			count, err := strconv.ParseUint(q.After.Key, 10, 32)
+			if err != nil {
+				return nil, pagination.ErrInvalidCursor
+			}
+			after.FollowersCount = uint(count)
+		case repository.UserSortCreatedAt:
+			createdAt, err := time.Parse(time.RFC3339Nano, q.After.Key)
+			if err != nil {
+				return nil, pagination.ErrInvalidCursor
+			}
+			after.CreatedAt = createdAt
+		}
+		users = slices.DeleteFunc(users, func(u model.User) bool { return compare(&u, &after) <= 0 })
+	} else {
+		users = users[min(max(q.Page-1, 0)*q.PageSize, len(users)):]
+	}
+	return users[:min(q.PageSize+1, len(users))], nil
+}
+
+func (f *fakeUsers) CountUsers(repository.UserListQuery) (int64, error) {
+	return int64(len(f.users)), nil
+}
+
+func TestGetUsersPaginatedCursor(t *testing.T) {
+	base := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	var users []model.User
+	for i, name := range []string{"erin", "bob", "dave", "alice", "carol", "frank", "grace"} {
+		u := testUser(uint(i+1), name)
+		// Ties in both counts and creation times exercise the id tie breaker.
+		u.FollowersCount = uint(i % 3)
+		u.CreatedAt = base.Add(time.Duration(i/2) * 1500 * time.Microsecond)
+		users = append(users, u)
+	}
+	s := NewUserService(newFakeUsers(users...), nil, nil, nil, newMemStore(), nil, UsernamePolicy{}, PaginationPolicy{DefaultPageSize: 3, MaxPageSize: 3})
+
+	for _, sort := range []string{repository.UserSortCreatedAt, repository.UserSortUsername, repository.UserSortFollowersCount} {
+		for _, order := range []string{"asc", "desc"} {
+			t.Run(sort+" "+order, func(t *testing.T) {
+				// Offset pages give the expected order.
+				var want []uint
+				for page := 1; ; page++ {
+					res, err := s.GetUsersPaginated(request.ListUsersRequest{Page: page, Sort: sort, Order: order}, 0)
+					if err != nil {
+						t.Fatal(err)
+					}
+					for _, u := range res.Users {
+						want = append(want, u.ID)
+					}
+					if !res.HasNext {
+						break
+					}
+				}
+
+				var got []uint
+				cursor := ""
+				for pages := 0; pages < len(users); pages++ {
+					res, err := s.GetUsersPaginated(request.ListUsersRequest{PageSize: 10, Sort: sort, Order: order, Cursor: &cursor}, 0)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if res.Size != 3 {
+						t.Errorf("page size %d, want it clamped to 3", res.Size)
+					}
+					for _, u := range res.Users {
+						got = append(got, u.ID)
+					}
+					if res.HasNext != (res.NextCursor != "") {
+						t.Fatalf("HasNext %t with next cursor %q", res.HasNext, res.NextCursor)
+					}
+					if !res.HasNext {
+						break
+					}
+					cursor = res.NextCursor
+				}
+
+				if len(want) != len(users) || !slices.Equal(got, want) {
+					t.Errorf("cursor pages %v, offset pages %v", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestGetUsersPaginatedRejectsForeignCursor(t *testing.T) {
+	s := NewUserService(newFakeUsers(testUser(1, "alice")), nil, nil, nil, newMemStore(), nil, UsernamePolicy{}, PaginationPolicy{DefaultPageSize: 3, MaxPageSize: 3})
+
+	tests := []struct {
+		name   string
+		cursor pagination.Cursor
+		sort   string
+		order  string
+	}{
+		{"other column", pagination.Cursor{Key: "alice", ID: 1, Sort: "username"}, "created_at", "asc"},
+		{"other direction", pagination.Cursor{Key: "alice", ID: 1, Sort: "username"}, "username", "desc"},
+		{"no sort", pagination.Cursor{Key: "alice", ID: 1}, "username", "asc"},
+		{"search cursor", pagination.Cursor{Key: "3", ID: 1}, "", ""},
+		{"unparsable key", pagination.Cursor{Key: "yesterday", ID: 1, Sort: "created_at"}, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor := tt.cursor.Encode()
+			_, err := s.GetUsersPaginated(request.ListUsersRequest{Sort: tt.sort, Order: tt.order, Cursor: &cursor}, 0)
+			if !errors.Is(err, pagination.ErrInvalidCursor) {
+				t.Errorf("error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
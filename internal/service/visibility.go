@@ -0,0 +1,53 @@
+package service
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"user_service/internal/auth"
+	"user_service/internal/model"
+	"user_service/internal/repository"
+)
+
+// isPrivate reports whether userID has a private profile.
+// Users without a settings row are treated as public.
+func isPrivate(settings repository.SettingsRepository, userID uint) (bool, error) {
+	s, err := settings.GetByUserID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return s.IsPrivate, nil
+}
+
+// canSeePrivate reports whether viewer may see the private parts of userID's profile,
+// which only the owner, approved followers and services granted scope can. A nil
+// viewer is an anonymous caller.
+func canSeePrivate(relations repository.FollowerRelationRepository, userID uint, viewer *auth.Principal, scope string) (bool, error) {
+	switch {
+	case viewer == nil:
+		return false, nil
+	case viewer.IsService():
+		return viewer.HasScope(scope), nil
+	case viewer.UserID == userID:
+		return true, nil
+	}
+
+	relation, err := relations.GetByPair(userID, viewer.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return relation.Status == model.StatusApproved, nil
+}
+
+// viewerUserID is the user id of viewer, zero for anonymous callers and services.
+func viewerUserID(viewer *auth.Principal) uint {
+	if viewer == nil {
+		return 0
+	}
+	return viewer.UserID
+}
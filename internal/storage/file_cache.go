@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// FileCache keeps derived files, such as resized images, on local disk. Entries
+// never expire, callers put a version into their keys instead, and the
+// directory can be wiped at any time.
+type FileCache struct {
+	root string
+}
+
+func NewFileCache(root string) (*FileCache, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("create cache directory: %w", err)
+	}
+	return &FileCache{root: root}, nil
+}
+
+// Get returns the cached content of key or ErrNotFound.
+func (c *FileCache) Get(key string) ([]byte, error) {
+	name, err := keyPath(c.root, key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+func (c *FileCache) Put(key string, data []byte) error {
+	name, err := keyPath(c.root, key)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(name, bytes.NewReader(data))
+}
+
+// DeleteDir removes every entry whose key starts with dir followed by a slash.
+func (c *FileCache) DeleteDir(dir string) error {
+	name, err := keyPath(c.root, dir)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(name)
+}
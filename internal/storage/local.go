@@ -0,0 +1,206 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalConfig configures a LocalStore.
+type LocalConfig struct {
+	// Root is the directory the blobs are stored in.
+	Root string
+	// BaseURL is where the server mounts the store, see LocalStore.Open.
+	BaseURL string
+	// SigningKey authenticates signed URLs.
+	SigningKey []byte
+	// PublicPrefixes lists key prefixes readable without a signature.
+	PublicPrefixes []string
+}
+
+// LocalStore keeps blobs as files below a root directory. The server serves them
+// itself, so the store also checks the signatures of its signed URLs.
+type LocalStore struct {
+	cfg LocalConfig
+}
+
+func NewLocalStore(cfg LocalConfig) (*LocalStore, error) {
+	if len(cfg.SigningKey) == 0 {
+		return nil, errors.New("local storage needs a signing key")
+	}
+	if err := os.MkdirAll(cfg.Root, 0o755); err != nil {
+		return nil, fmt.Errorf("create storage directory: %w", err)
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+	return &LocalStore{cfg: cfg}, nil
+}
+
+func (s *LocalStore) Put(_ context.Context, key string, r io.Reader, _ string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(name, r)
+}
+
+func (s *LocalStore) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	return s.Open(key)
+}
+
+// Open returns the file behind key for serving it with http.ServeContent.
+func (s *LocalStore) Open(key string) (*os.File, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	f, err := os.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if info, err := f.Stat(); err != nil || info.IsDir() {
+		f.Close()
+		return nil, ErrNotFound
+	}
+	return f, nil
+}
+
+func (s *LocalStore) Delete(_ context.Context, key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStore) Walk(ctx context.Context, prefix string, fn func(key string) error) error {
+	// Only the directory holding the prefix has to be scanned.
+	dir := s.cfg.Root
+	if i := strings.LastIndex(prefix, "/"); i > 0 {
+		dir = filepath.Join(s.cfg.Root, filepath.FromSlash(prefix[:i]))
+	}
+
+	err := filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.cfg.Root, name)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		return fn(key)
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *LocalStore) URL(key string) string {
+	return s.cfg.BaseURL + "/" + key
+}
+
+func (s *LocalStore) SignedURL(_ context.Context, key string, ttl time.Duration) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	query := url.Values{"expires": {expires}, "signature": {s.sign(key, expires)}}
+	return s.URL(key) + "?" + query.Encode(), nil
+}
+
+// IsPublic reports whether key may be read without a signature.
+func (s *LocalStore) IsPublic(key string) bool {
+	for _, prefix := range s.cfg.PublicPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Verify checks the expires and signature parameters of a URL made by SignedURL.
+func (s *LocalStore) Verify(key, expires, signature string) error {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.sign(key, expires))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func (s *LocalStore) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, s.cfg.SigningKey)
+	mac.Write([]byte(key + "\n" + expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *LocalStore) path(key string) (string, error) {
+	return keyPath(s.cfg.Root, key)
+}
+
+// keyPath maps key to a file below root, rejecting keys that would escape it.
+func keyPath(root, key string) (string, error) {
+	clean := path.Clean("/" + key)[1:]
+	if clean == "" || clean != key {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(root, filepath.FromSlash(clean)), nil
+}
+
+// writeFileAtomic writes data to name through a temporary file, so readers
+// never see a partial file.
+func writeFileAtomic(name string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
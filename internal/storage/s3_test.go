@@ -0,0 +1,206 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeS3 serves a single bucket of a path style S3 API from memory, listing
+// pageSize keys per page.
+type fakeS3 struct {
+	t        *testing.T
+	bucket   string
+	pageSize int
+
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+	lists   int
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), sigV4Algorithm+" Credential=access/") {
+		f.t.Errorf("%s %s is not signed", r.Method, r.URL)
+	}
+
+	key, ok := strings.CutPrefix(r.URL.Path, "/"+f.bucket+"/")
+	if !ok {
+		http.Error(w, "unknown bucket", http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		if got := r.Header.Get("X-Amz-Content-Sha256"); got != sha256Hex(body) {
+			f.t.Errorf("payload hash %s does not match the body", got)
+		}
+		f.objects[key] = body
+		f.types[key] = r.Header.Get("Content-Type")
+	case r.Method == http.MethodGet && key == "":
+		f.list(w, r)
+	case r.Method == http.MethodGet:
+		body, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, "<Error><Code>NoSuchKey</Code></Error>")
+			return
+		}
+		w.Write(body)
+	case r.Method == http.MethodDelete:
+		if _, ok := f.objects[key]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, r *http.Request) {
+	f.lists++
+	query := r.URL.Query()
+	if query.Get("list-type") != "2" {
+		http.Error(w, "only ListObjectsV2 is supported", http.StatusBadRequest)
+		return
+	}
+
+	var keys []string
+	for key := range f.objects {
+		if strings.HasPrefix(key, query.Get("prefix")) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	start := 0
+	if token := query.Get("continuation-token"); token != "" {
+		start, _ = strconv.Atoi(token)
+	}
+	end := min(start+f.pageSize, len(keys))
+
+	var result listBucketResult
+	for _, key := range keys[start:end] {
+		result.Contents = append(result.Contents, struct {
+			Key string `xml:"Key"`
+		}{key})
+	}
+	if end < len(keys) {
+		result.IsTruncated = true
+		result.NextContinuationToken = strconv.Itoa(end)
+	}
+	xml.NewEncoder(w).Encode(result)
+}
+
+func newTestS3Store(t *testing.T) (*S3Store, *fakeS3) {
+	fake := &fakeS3{t: t, bucket: "media", pageSize: 2, objects: make(map[string][]byte), types: make(map[string]string)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	store, err := NewS3Store(S3Config{
+		Endpoint:  server.URL,
+		Bucket:    "media",
+		AccessKey: "access",
+		SecretKey: "secret",
+		PathStyle: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store, fake
+}
+
+func TestS3StorePutGetDelete(t *testing.T) {
+	store, fake := newTestS3Store(t)
+	ctx := context.Background()
+
+	if err := store.Put(ctx, "avatars/7/256 px.jpg", strings.NewReader("jpeg"), "image/jpeg"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if got := fake.types["avatars/7/256 px.jpg"]; got != "image/jpeg" {
+		t.Errorf("stored content type %q", got)
+	}
+
+	rc, err := store.Get(ctx, "avatars/7/256 px.jpg")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil || string(data) != "jpeg" {
+		t.Errorf("Get = %q, %v", data, err)
+	}
+
+	if err := store.Delete(ctx, "avatars/7/256 px.jpg"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(ctx, "avatars/7/256 px.jpg"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete: got %v, want ErrNotFound", err)
+	}
+	// Deleting is idempotent, as for the local store.
+	if err := store.Delete(ctx, "avatars/7/256 px.jpg"); err != nil {
+		t.Errorf("Delete of a missing key: %v", err)
+	}
+}
+
+func TestS3StoreWalk(t *testing.T) {
+	store, fake := newTestS3Store(t)
+	ctx := context.Background()
+
+	want := []string{"avatars/1/64.jpg", "avatars/2/64.jpg", "avatars/3/64.jpg", "avatars/4/64.jpg", "avatars/5/64.jpg"}
+	for _, key := range append([]string{"other/1"}, want...) {
+		if err := store.Put(ctx, key, bytes.NewReader(nil), ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []string
+	err := store.Walk(ctx, "avatars/", func(key string) error {
+		got = append(got, key)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Walk visited %v, want %v", got, want)
+	}
+	if fake.lists != 3 {
+		t.Errorf("listed %d pages, want 3", fake.lists)
+	}
+
+	stop := errors.New("stop")
+	var visited int
+	err = store.Walk(ctx, "avatars/", func(string) error {
+		visited++
+		return stop
+	})
+	if !errors.Is(err, stop) || visited != 1 {
+		t.Errorf("Walk after an error from fn: %v after %d keys", err, visited)
+	}
+}
+
+func TestS3StoreErrorStatus(t *testing.T) {
+	store, _ := newTestS3Store(t)
+	store.cfg.Bucket = "missing"
+
+	err := store.Put(context.Background(), "key", strings.NewReader("x"), "")
+	if err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Put into an unknown bucket: %v", err)
+	}
+}
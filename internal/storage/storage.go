@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+var (
+	ErrNotFound         = errors.New("blob not found")
+	ErrInvalidSignature = errors.New("invalid or expired blob signature")
+)
+
+// BlobStore keeps binary objects such as avatar images under slash separated keys.
+type BlobStore interface {
+	// Put stores the content read from r under key, replacing any previous object.
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	// Get opens the object stored under key or returns ErrNotFound.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object under key. Missing objects are not an error.
+	Delete(ctx context.Context, key string) error
+	// Walk calls fn with every key starting with prefix, stopping at the first error.
+	Walk(ctx context.Context, prefix string, fn func(key string) error) error
+	// URL returns the unsigned address of the object, usable when its key is public.
+	URL(key string) string
+	// SignedURL returns an address granting read access to the object until ttl passes.
+	SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error)
+}
@@ -0,0 +1,12 @@
+package request
+
+import "time"
+
+type CreateAPIKeyRequest struct {
+	Name string `json:"name" binding:"required,max=64"`
+	// Scopes are read scopes only: every write acts on a user account and
+	// needs a token of its user.
+	Scopes []string `json:"scopes" binding:"required,min=1,dive,oneof=users:read follows:read"`
+	// ExpiresAt is optional, keys without it stay valid until revoked.
+	ExpiresAt *time.Time `json:"expires_at"`
+}
@@ -0,0 +1,7 @@
+package request
+
+// UpdateSettingsRequest is a partial update, omitted fields keep their current value.
+type UpdateSettingsRequest struct {
+	IsPrivate *bool `json:"is_private"`
+	DarkMode  *bool `json:"dark_mode"`
+}
@@ -1,11 +1,29 @@
 package request
 
+import "time"
+
 type CreateUserRequest struct {
-	Username string `json:"username" binding:"required"`
-	Bio      string `json:"bio"`
+	Username  string `json:"username" binding:"required,min=3,max=30,username,notreserved"`
+	Bio       string `json:"bio" binding:"max=160"`
+	AvatarURL string `json:"avatar_url" binding:"omitempty,max=2048,http_url"`
 }
 
 type UpdateUserRequest struct {
-	Username string `json:"username"`
-	Bio      string `json:"bio"`
+	Username  string `json:"username" binding:"omitempty,min=3,max=30,username,notreserved"`
+	Bio       string `json:"bio" binding:"max=160"`
+	AvatarURL string `json:"avatar_url" binding:"omitempty,max=2048,http_url"`
+}
+
+// ListUsersRequest holds the query parameters of the user listing.
+type ListUsersRequest struct {
+	Page          int        `form:"page" binding:"omitempty,min=1"`
+	PageSize      int        `form:"page_size" binding:"omitempty,min=1"`
+	Sort          string     `form:"sort" binding:"omitempty,oneof=created_at username followers_count"`
+	Order         string     `form:"order" binding:"omitempty,oneof=asc desc"`
+	Username      string     `form:"username" binding:"omitempty,max=30"`
+	CreatedAfter  *time.Time `form:"created_after" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedBefore *time.Time `form:"created_before" time_format:"2006-01-02T15:04:05Z07:00"`
+	MinFollowers  *uint      `form:"min_followers"`
+	// Cursor selects keyset pagination when present, an empty value starts from the top.
+	Cursor *string `form:"cursor"`
 }
@@ -0,0 +1,58 @@
+package request
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// usernamePattern allows letters, digits and underscores, optionally separated by single dots.
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_]+(\.[A-Za-z0-9_]+)*$`)
+
+// reservedUsernames would clash with routes or impersonate the platform.
+var reservedUsernames = map[string]struct{}{
+	"admin":         {},
+	"administrator": {},
+	"api":           {},
+	"by-username":   {},
+	"me":            {},
+	"moderator":     {},
+	"root":          {},
+	"search":        {},
+	"settings":      {},
+	"support":       {},
+	"system":        {},
+}
+
+// RegisterValidators installs the custom rules used in request binding tags
+// and reports field names by their JSON or query parameter names.
+func RegisterValidators() error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return errors.New("unexpected binding validator engine")
+	}
+
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "form"} {
+			name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+			if name != "" && name != "-" {
+				return name
+			}
+		}
+		return ""
+	})
+
+	if err := v.RegisterValidation("username", func(fl validator.FieldLevel) bool {
+		return usernamePattern.MatchString(fl.Field().String())
+	}); err != nil {
+		return err
+	}
+
+	return v.RegisterValidation("notreserved", func(fl validator.FieldLevel) bool {
+		_, reserved := reservedUsernames[strings.ToLower(fl.Field().String())]
+		return !reserved
+	})
+}
@@ -0,0 +1,16 @@
+package response
+
+import "time"
+
+type FollowRelationResponse struct {
+	ID         uint      `json:"id"`
+	UserID     uint      `json:"user_id"`
+	FollowerID uint      `json:"follower_id"`
+	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type FollowListResponse struct {
+	Users      []UserResponseShort `json:"users"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,9 @@
+package response
+
+import "time"
+
+type SettingsResponse struct {
+	IsPrivate bool      `json:"is_private"`
+	DarkMode  bool      `json:"dark_mode"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
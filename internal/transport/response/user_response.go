@@ -7,6 +7,17 @@ type UserResponseFull struct {
 	Bio            string `json:"bio"`
 	FollowersCount uint   `json:"followers_count"`
 	FollowingCount uint   `json:"following_count"`
+	// Restricted is set when private fields were left out because the viewer
+	// is not an approved follower.
+	Restricted bool `json:"restricted,omitempty"`
+}
+
+// UserResponseMe is the private view of the caller's own profile.
+type UserResponseMe struct {
+	UserResponseFull
+	Settings              SettingsResponse `json:"settings"`
+	PendingRequestsCount  int64            `json:"pending_requests_count"`
+	OutgoingRequestsCount int64            `json:"outgoing_requests_count"`
 }
 
 type UserResponseShort struct {
@@ -17,7 +28,23 @@ type UserResponseShort struct {
 
 type PaginatedUsersResponse struct {
 	Users []UserResponseShort `json:"users"`
-	Total int                 `json:"total"`
-	Page  int                 `json:"page"`
-	Size  int                 `json:"size"`
+	Total int64               `json:"total"`
+	// TotalEstimated is set when Total comes from planner statistics instead of COUNT(*).
+	// The estimate lags behind recent sign-ups and deletions until the next ANALYZE.
+	TotalEstimated bool `json:"total_estimated,omitempty"`
+	// Page is omitted in cursor mode, where NextCursor continues the listing instead.
+	Page       int    `json:"page,omitempty"`
+	Size       int    `json:"size"`
+	HasNext    bool   `json:"has_next"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// UsersResponse holds the users looked up by id.
+type UsersResponse struct {
+	Users []UserResponseFull `json:"users"`
+}
+
+type SearchUsersResponse struct {
+	Users      []UserResponseShort `json:"users"`
+	NextCursor string              `json:"next_cursor,omitempty"`
 }
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_follower_user_list;
+DROP INDEX IF EXISTS idx_follower_follower_list;
\ No newline at end of file
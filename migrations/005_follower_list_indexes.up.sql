@@ -0,0 +1,3 @@
+-- Keyset pagination of followers / following by (created_at, id)
+CREATE INDEX idx_follower_user_list ON follower_relations(user_id, status, created_at DESC, id DESC);
+CREATE INDEX idx_follower_follower_list ON follower_relations(follower_id, status, created_at DESC, id DESC);
\ No newline at end of file
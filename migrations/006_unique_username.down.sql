@@ -0,0 +1 @@
+DROP INDEX IF EXISTS uniq_users_username_ci;
\ No newline at end of file
@@ -0,0 +1,13 @@
+-- Существующие дубликаты (без учета регистра) переименовываем, старейший аккаунт сохраняет имя.
+-- Символ '#' запрещен в именах пользователей, а id уникален, поэтому новое имя не может совпасть с существующим
+UPDATE users u
+SET username = u.username || '#' || u.id
+FROM (
+         SELECT id, ROW_NUMBER() OVER (PARTITION BY LOWER(username) ORDER BY id) AS rn
+         FROM users
+         WHERE deleted_at IS NULL
+     ) d
+WHERE u.id = d.id AND d.rn > 1;
+
+-- Уникальное имя пользователя без учета регистра, удаленные аккаунты не учитываются
+CREATE UNIQUE INDEX uniq_users_username_ci ON users (LOWER(username)) WHERE deleted_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_username_history_old_username;
+DROP INDEX IF EXISTS idx_username_history_user_id;
+DROP TABLE IF EXISTS username_history;
\ No newline at end of file
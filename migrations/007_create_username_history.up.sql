@@ -0,0 +1,14 @@
+CREATE TABLE username_history (
+                                  id SERIAL PRIMARY KEY,
+                                  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                  old_username VARCHAR(255) NOT NULL,
+                                  new_username VARCHAR(255) NOT NULL,
+                                  changed_at TIMESTAMPTZ DEFAULT NOW(),
+                                  reserved_until TIMESTAMPTZ NOT NULL
+);
+
+-- Последняя смена имени пользователя (cooldown)
+CREATE INDEX idx_username_history_user_id ON username_history(user_id, changed_at DESC);
+
+-- Поиск зарезервированных имен
+CREATE INDEX idx_username_history_old_username ON username_history(LOWER(old_username), reserved_until DESC);
\ No newline at end of file
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_users_followers_count;
+DROP INDEX IF EXISTS idx_users_bio_trgm;
+DROP INDEX IF EXISTS idx_users_bio_tsv;
+DROP INDEX IF EXISTS idx_users_username_prefix;
\ No newline at end of file
@@ -0,0 +1,11 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Поиск по префиксу имени пользователя
+CREATE INDEX idx_users_username_prefix ON users (LOWER(username) text_pattern_ops) WHERE deleted_at IS NULL;
+
+-- Полнотекстовый и нечеткий поиск по bio
+CREATE INDEX idx_users_bio_tsv ON users USING GIN (to_tsvector('simple', COALESCE(bio, ''))) WHERE deleted_at IS NULL;
+CREATE INDEX idx_users_bio_trgm ON users USING GIN (COALESCE(bio, '') gin_trgm_ops) WHERE deleted_at IS NULL;
+
+-- Сортировка результатов по популярности
+CREATE INDEX idx_users_followers_count ON users (followers_count DESC, id DESC) WHERE deleted_at IS NULL;
\ No newline at end of file
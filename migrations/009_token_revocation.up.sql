@@ -0,0 +1,15 @@
+CREATE TABLE revoked_tokens (
+                                jti VARCHAR(255) PRIMARY KEY,
+                                user_id INTEGER NOT NULL,
+                                expires_at TIMESTAMPTZ NOT NULL,
+                                revoked_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Удаление записей об истекших токенах
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens(expires_at);
+
+-- Токены пользователя, выданные до revoked_before, недействительны
+CREATE TABLE token_cutoffs (
+                               user_id INTEGER PRIMARY KEY,
+                               revoked_before TIMESTAMPTZ NOT NULL
+);
\ No newline at end of file
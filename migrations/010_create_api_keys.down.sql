@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS uniq_api_keys_key_hash;
+DROP TABLE IF EXISTS api_keys;
\ No newline at end of file
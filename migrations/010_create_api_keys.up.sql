@@ -0,0 +1,15 @@
+CREATE TABLE api_keys (
+                          id SERIAL PRIMARY KEY,
+                          name VARCHAR(64) NOT NULL,
+                          prefix VARCHAR(16) NOT NULL,
+                          key_hash CHAR(64) NOT NULL,
+                          scopes TEXT NOT NULL,
+                          created_by INTEGER NOT NULL,
+                          created_at TIMESTAMPTZ DEFAULT NOW(),
+                          expires_at TIMESTAMPTZ,
+                          last_used_at TIMESTAMPTZ,
+                          revoked_at TIMESTAMPTZ
+);
+
+-- Поиск ключа по хешу при аутентификации
+CREATE UNIQUE INDEX uniq_api_keys_key_hash ON api_keys(key_hash);
\ No newline at end of file
@@ -0,0 +1,111 @@
+package identicon
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+)
+
+const gridSize = 5
+
+var background = color.RGBA{R: 0xF0, G: 0xF0, B: 0xF0, A: 0xFF}
+
+// Identicon is a horizontally symmetric 5x5 pattern derived from a name, so the
+// same name always renders the same picture.
+type Identicon struct {
+	cells [gridSize][gridSize]bool
+	color color.RGBA
+}
+
+// New derives the identicon of name, ignoring letter case.
+func New(name string) Identicon {
+	sum := sha256.Sum256([]byte(strings.ToLower(name)))
+
+	var id Identicon
+	// The left three columns come from the hash, the right two mirror them.
+	for row := 0; row < gridSize; row++ {
+		for col := 0; col < (gridSize+1)/2; col++ {
+			on := sum[row*3+col]&1 == 1
+			id.cells[row][col] = on
+			id.cells[row][gridSize-1-col] = on
+		}
+	}
+
+	hue := float64(uint16(sum[29])<<8|uint16(sum[30])) / 65536 * 360
+	id.color = hslToRGB(hue, 0.55, 0.55)
+	return id
+}
+
+// Image renders the identicon as a size by size image with a margin of half a cell.
+func (id Identicon) Image(size int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	cell := float64(size) / (gridSize + 1)
+	margin := cell / 2
+
+	for y := 0; y < size; y++ {
+		row := int(math.Floor((float64(y) + 0.5 - margin) / cell))
+		for x := 0; x < size; x++ {
+			col := int(math.Floor((float64(x) + 0.5 - margin) / cell))
+			c := background
+			if row >= 0 && row < gridSize && col >= 0 && col < gridSize && id.cells[row][col] {
+				c = id.color
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// SVG renders the identicon as a scalable image of size by size pixels.
+func (id Identicon) SVG(size int) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		size, size, gridSize+1, gridSize+1)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="%s"/>`, gridSize+1, gridSize+1, hexColor(background))
+	for row := 0; row < gridSize; row++ {
+		for col := 0; col < gridSize; col++ {
+			if id.cells[row][col] {
+				fmt.Fprintf(&b, `<rect x="%d.5" y="%d.5" width="1" height="1" fill="%s"/>`, col, row, hexColor(id.color))
+			}
+		}
+	}
+	b.WriteString(`</svg>`)
+	return b.Bytes()
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func hslToRGB(h, s, l float64) color.RGBA {
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := l - c/2
+
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+
+	return color.RGBA{
+		R: uint8(math.Round((r + m) * 255)),
+		G: uint8(math.Round((g + m) * 255)),
+		B: uint8(math.Round((b + m) * 255)),
+		A: 0xFF,
+	}
+}
@@ -0,0 +1,135 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"slices"
+	"testing"
+)
+
+// letterImage paints rows of letters as pixels whose red channel holds the
+// letter, so that transformed images can be compared as text.
+func letterImage(rows ...string) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, len(rows[0]), len(rows)))
+	for y, row := range rows {
+		for x := range row {
+			img.SetRGBA(x, y, color.RGBA{R: row[x], A: 255})
+		}
+	}
+	return img
+}
+
+func imageLetters(img *image.RGBA) []string {
+	var rows []string
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		var row []byte
+		for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+			row = append(row, img.RGBAAt(x, y).R)
+		}
+		rows = append(rows, string(row))
+	}
+	return rows
+}
+
+func TestOrient(t *testing.T) {
+	src := []string{
+		"ABC",
+		"DEF",
+	}
+
+	tests := []struct {
+		orientation int
+		want        []string
+	}{
+		{0, []string{"ABC", "DEF"}},
+		{1, []string{"ABC", "DEF"}},
+		{2, []string{"CBA", "FED"}},
+		{3, []string{"FED", "CBA"}},
+		{4, []string{"DEF", "ABC"}},
+		{5, []string{"AD", "BE", "CF"}},
+		{6, []string{"DA", "EB", "FC"}},
+		{7, []string{"FC", "EB", "DA"}},
+		{8, []string{"CF", "BE", "AD"}},
+		{9, []string{"ABC", "DEF"}},
+	}
+
+	for _, tt := range tests {
+		if got := imageLetters(Orient(letterImage(src...), tt.orientation)); !slices.Equal(got, tt.want) {
+			t.Errorf("Orient(%d) = %q, want %q", tt.orientation, got, tt.want)
+		}
+
+		// Sub-images, like the centered square crop, start off the origin.
+		padded := letterImage("xxxxx", "x"+src[0]+"x", "x"+src[1]+"x", "xxxxx")
+		sub := padded.SubImage(image.Rect(1, 1, 4, 3)).(*image.RGBA)
+		if got := imageLetters(Orient(sub, tt.orientation)); !slices.Equal(got, tt.want) {
+			t.Errorf("Orient(%d) of a sub-image = %q, want %q", tt.orientation, got, tt.want)
+		}
+	}
+}
+
+// exifJPEG returns a JPEG with an APP1 segment holding a TIFF header in order
+// whose first IFD has the given entries, each a tag and a SHORT value.
+func exifJPEG(t *testing.T, order binary.AppendByteOrder, entries ...[2]uint16) []byte {
+	t.Helper()
+
+	var img bytes.Buffer
+	if err := jpeg.Encode(&img, image.NewRGBA(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tiff := []byte("II")
+	if order == binary.BigEndian {
+		tiff = []byte("MM")
+	}
+	tiff = order.AppendUint16(tiff, 42)
+	tiff = order.AppendUint32(tiff, 8)
+	tiff = order.AppendUint16(tiff, uint16(len(entries)))
+	for _, e := range entries {
+		tiff = order.AppendUint16(tiff, e[0])
+		tiff = order.AppendUint16(tiff, 3)
+		tiff = order.AppendUint32(tiff, 1)
+		tiff = order.AppendUint16(tiff, e[1])
+		tiff = append(tiff, 0, 0)
+	}
+	tiff = order.AppendUint32(tiff, 0)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	out := []byte{0xFF, 0xD8, 0xFF, 0xE1}
+	out = binary.BigEndian.AppendUint16(out, uint16(len(segment)+2))
+	out = append(out, segment...)
+	return append(out, img.Bytes()[2:]...)
+}
+
+func TestOrientation(t *testing.T) {
+	var plain bytes.Buffer
+	if err := jpeg.Encode(&plain, image.NewRGBA(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"big endian", exifJPEG(t, binary.BigEndian, [2]uint16{exifOrientationTag, 6}), 6},
+		{"little endian", exifJPEG(t, binary.LittleEndian, [2]uint16{exifOrientationTag, 8}), 8},
+		{"after other tags", exifJPEG(t, binary.BigEndian, [2]uint16{0x010F, 1}, [2]uint16{0x0110, 2}, [2]uint16{exifOrientationTag, 3}), 3},
+		{"out of range", exifJPEG(t, binary.LittleEndian, [2]uint16{exifOrientationTag, 9}), 1},
+		{"no orientation tag", exifJPEG(t, binary.BigEndian, [2]uint16{0x010F, 6}), 1},
+		{"no EXIF", plain.Bytes(), 1},
+		{"truncated", exifJPEG(t, binary.BigEndian, [2]uint16{exifOrientationTag, 6})[:20], 1},
+		{"not a JPEG", []byte("\x89PNG\r\n\x1a\n"), 1},
+		{"empty", nil, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Orientation(tt.data); got != tt.want {
+				t.Errorf("Orientation = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
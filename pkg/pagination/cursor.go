@@ -0,0 +1,43 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is an opaque keyset position: the sort key of the last returned row and its id
+// as a tie breaker.
+type Cursor struct {
+	Key string `json:"k"`
+	ID  uint   `json:"i"`
+	// Sort names the ordering the cursor was issued for on lists that offer several.
+	Sort string `json:"s,omitempty"`
+}
+
+// Encode serializes the cursor into a URL safe string.
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor produced by Encode.
+// An empty string yields a nil cursor, meaning the first page.
+func DecodeCursor(s string) (*Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
@@ -0,0 +1,67 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []Cursor{
+		{Key: "2026-01-01T12:00:00.123456789Z", ID: 1},
+		{Key: "alice", ID: 42, Sort: "-username"},
+		{Key: "", ID: 7},
+		{Key: "ключ, \"quoted\" & ?=/+", ID: 1<<32 - 1},
+	}
+
+	for _, c := range tests {
+		encoded := c.Encode()
+		got, err := DecodeCursor(encoded)
+		if err != nil {
+			t.Fatalf("DecodeCursor(%q) of %+v: %v", encoded, c, err)
+		}
+		if *got != c {
+			t.Errorf("DecodeCursor(Encode(%+v)) = %+v", c, *got)
+		}
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	raw := func(s string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(s))
+	}
+
+	tests := []struct {
+		name    string
+		cursor  string
+		wantNil bool
+		wantErr bool
+	}{
+		{"empty starts at the top", "", true, false},
+		{"valid", raw(`{"k":"a","i":3}`), false, false},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"k":"ab","i":3}`)), true, true},
+		{"standard base64 alphabet", "+/+/", true, true},
+		{"truncated base64", "a", true, true},
+		{"not JSON", raw("hello"), true, true},
+		{"JSON array", raw(`[1,2]`), true, true},
+		{"missing id", raw(`{"k":"a"}`), true, true},
+		{"zero id", raw(`{"k":"a","i":0}`), true, true},
+		{"negative id", raw(`{"k":"a","i":-1}`), true, true},
+		{"string id", raw(`{"k":"a","i":"3"}`), true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeCursor(tt.cursor)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("DecodeCursor error = %v, want error %t", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("DecodeCursor error = %v, want ErrInvalidCursor", err)
+			}
+			if tt.wantNil != (got == nil) {
+				t.Errorf("DecodeCursor = %+v, want nil %t", got, tt.wantNil)
+			}
+		})
+	}
+}